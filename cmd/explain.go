@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"json2cpp/internal/codegen"
+	"json2cpp/internal/types"
+
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Print the inferred type model and how each type was chosen",
+	Long: `Print the inferred model as a tree before code generation.
+
+	For every struct the JSON path of the sampled object is shown, and for every
+	field the chosen C++ type, its optionality, the input files that contributed
+	it, and the renames and promotions that were applied on the way. The C++
+	types follow the --parser, --std, --legacy-cpp, --optional-null and
+	--nullable flags as in generation.`,
+	RunE: runExplain,
+}
+
+func init() {
+	addParseFlags(explainCmd)
+	addTypeFlags(explainCmd)
+	rootCmd.AddCommand(explainCmd)
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	cfg, err := generatorConfig()
+	if err != nil {
+		return err
+	}
+	allStructs, err := collectStructs()
+	if err != nil {
+		return err
+	}
+
+	gen := codegen.NewAdapterGenerator(cfg, "")
+	explainStructs(cmd.OutOrStdout(), gen, allStructs)
+	return nil
+}
+
+// explainStructs prints every root struct (one that is not nested in another)
+// followed by its fields, descending into nested structs.
+func explainStructs(w io.Writer, gen *codegen.AdapterGenerator, structs []*types.Struct) {
+	nested := make(map[*types.Struct]bool)
+	for _, s := range structs {
+		for _, f := range s.Fields {
+			if f.NestedType != nil {
+				nested[f.NestedType] = true
+			}
+		}
+		if s.Union != nil {
+			for _, v := range s.Union.Variants {
+				nested[v.Type] = true
+			}
+		}
+	}
+
+	printed := make(map[*types.Struct]bool)
+	for _, s := range structs {
+		if !nested[s] {
+			explainStruct(w, gen, s, 0, printed)
+		}
+	}
+}
+
+func explainStruct(w io.Writer, gen *codegen.AdapterGenerator, s *types.Struct, depth int, printed map[*types.Struct]bool) {
+	indent := strings.Repeat("    ", depth)
+	path := s.Path
+	if path == "" {
+		path = "/"
+	}
+	if printed[s] {
+		fmt.Fprintf(w, "%sstruct %s (see above)\n", indent, s.Name)
+		return
+	}
+	printed[s] = true
+
+	fmt.Fprintf(w, "%sstruct %s  [%s]\n", indent, s.Name, path)
+	if s.Base != nil {
+		fmt.Fprintf(w, "%s  inherits %s\n", indent, s.Base.Name)
+	}
+	if s.Union != nil {
+		fmt.Fprintf(w, "%s  tagged union on %q\n", indent, s.Union.Tag)
+		for _, v := range s.Union.Variants {
+			fmt.Fprintf(w, "%s  %s == %q:\n", indent, s.Union.Tag, v.Value)
+			explainStruct(w, gen, v.Type, depth+1, printed)
+		}
+	}
+	for _, f := range s.Fields {
+		cppType, err := gen.CppType(f)
+		if err != nil {
+			cppType = "<" + err.Error() + ">"
+		}
+		optionality := "required"
+		if f.IsOptional {
+			optionality = "optional"
+		}
+		fmt.Fprintf(w, "%s  %s: %s  (%s, %s)  [%s]\n", indent, f.Name, cppType, types.DescribeJSONType(f), optionality, f.Path)
+		if len(f.Sources) > 0 {
+			fmt.Fprintf(w, "%s      from: %s\n", indent, strings.Join(f.Sources, ", "))
+		}
+		if len(f.Examples) > 0 {
+			fmt.Fprintf(w, "%s      e.g.: %s\n", indent, strings.Join(f.Examples, ", "))
+		}
+		for _, step := range f.Trace {
+			fmt.Fprintf(w, "%s      %s\n", indent, step)
+		}
+		if f.NestedType != nil {
+			explainStruct(w, gen, f.NestedType, depth+1, printed)
+		}
+	}
+}
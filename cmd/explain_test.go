@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// explain shows the member types the generator would declare under the same
+// flags
+func TestExplainFollowsGeneratorFlags(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "sample.json")
+	if err := os.WriteFile(input, []byte(`{"id": 1, "note": null, "tags": [1, "x"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		flags   []string
+		want    []string
+		wantErr string
+	}{
+		{"defaults", []string{"--optional-null=false", "--std", "11", "--promotion", "widening"},
+			[]string{"  id: int64_t  (int, required)", "  note: bool  (null, optional)", "  tags: std::vector<std::string>  "}, ""},
+		{"Optional", []string{"--optional-null", "--std", "11", "--promotion", "widening"},
+			[]string{"  note: Optional<bool>  (null, optional)"}, ""},
+		{"std::variant", []string{"--optional-null=false", "--std", "17", "--promotion", "variant"},
+			[]string{"  tags: std::vector<std::variant<int64_t, std::string>>  "}, ""},
+		{"variant before C++17", []string{"--optional-null=false", "--std", "11", "--promotion", "variant"},
+			[]string{"  tags: <field tags holds a variant of int, string, which needs --std 17>"}, ""},
+		{"unsupported standard", []string{"--std", "14"}, nil, "unsupported C++ standard: 14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			rootCmd.SetOut(&out)
+			rootCmd.SetErr(&bytes.Buffer{})
+			rootCmd.SetArgs(append([]string{"explain", "-i", input}, tt.flags...))
+			err := rootCmd.Execute()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output lacks %q:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+}
@@ -4,8 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 
 	"json2cpp/internal/codegen"
+	"json2cpp/internal/hints"
 	"json2cpp/internal/parser"
 	"json2cpp/internal/types"
 
@@ -15,17 +20,42 @@ import (
 const version = "1.2.0"
 
 var (
-	inputFile     string
-	outputDir     string
-	parserBackend string
-	legacyCpp     bool
-	namespace     string
-	camelCase     bool
-	optionalNull  bool
-	merge         bool
-	stringRef     bool
-	overwrite     bool
-	showVersion   bool
+	inputFile      string
+	outputDir      string
+	parserBackend  string
+	legacyCpp      bool
+	namespace      string
+	camelCase      bool
+	optionalNull   bool
+	nullable       bool
+	equality       bool
+	ordering       bool
+	hash           bool
+	sampleDefaults bool
+	classes        bool
+	getterPattern  string
+	setterPattern  string
+	builders       bool
+	merge          bool
+	batch          bool
+	jobs           int
+	strictMerge    bool
+	promotion      string
+	provenance     bool
+	docComments    bool
+	singularNames  bool
+	singularExcept map[string]string
+	detectUnions   bool
+	unionTags      []string
+	cppStandard    int
+	extractBases   int
+	decimalFields  []string
+	hintsFile      string
+	detectBase64   bool
+	detectNumeric  bool
+	stringRef      bool
+	overwrite      bool
+	showVersion    bool
 )
 
 var rootCmd = &cobra.Command{
@@ -37,9 +67,9 @@ var rootCmd = &cobra.Command{
 	- nlohmann
 	- jsoncpp
 
-	Generates: types.h (parser-independent) and serializer_<parser>.h/cpp (parser-specific).
+	Generates: types.h and types_io.h (parser-independent) and serializer_<parser>.h/cpp (parser-specific).
 	Requires C++11 or later.`,
-	RunE: run,
+	RunE:    run,
 	Version: version,
 }
 
@@ -51,16 +81,20 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.Flags().StringVarP(&inputFile, "input", "i", "", "Input JSON file (required)")
 	rootCmd.Flags().StringVarP(&outputDir, "output", "o", "./generated", "Output directory for generated files")
-	rootCmd.Flags().StringVarP(&parserBackend, "parser", "p", "rapidjson", "JSON parser backend (rapidjson, nlohmann, jsoncpp)")
-	rootCmd.Flags().BoolVar(&legacyCpp, "legacy-cpp", false, "Generate C++03 compatible code")
 	rootCmd.Flags().StringVar(&namespace, "namespace", "", "C++ namespace for generated types")
-	rootCmd.Flags().BoolVar(&camelCase, "camelcase", false, "Use camelCase for field names (default: snake_case)")
-	rootCmd.Flags().BoolVar(&optionalNull, "optional-null", false, "Generate Optional<T> for nullable fields")
-	rootCmd.Flags().BoolVar(&merge, "merge", false, "Merge multiple JSON files (supports wildcards)")
-
-	rootCmd.MarkFlagRequired("input")
+	rootCmd.Flags().BoolVar(&provenance, "provenance", false, "Comment each member in types.h with the sample files, JSON Pointers and example values it came from")
+	rootCmd.Flags().BoolVar(&docComments, "doc-comments", true, "Doxygen comments on structs and members in types.h with the JSON key, JSON Pointer, inferred type, optionality and an example value (--doc-comments=false to omit)")
+	rootCmd.Flags().BoolVar(&equality, "equality", false, "Generate operator== and operator!= for structs")
+	rootCmd.Flags().BoolVar(&ordering, "ordering", false, "Generate operator< for structs too (implies --equality)")
+	rootCmd.Flags().BoolVar(&hash, "hash", false, "Generate std::hash specializations and hash_value for structs")
+	rootCmd.Flags().BoolVar(&sampleDefaults, "sample-defaults", false, "Initialize scalar members with their value in the first sample instead of zero (hint defaults take precedence)")
+	rootCmd.Flags().BoolVar(&classes, "classes", false, "Generate classes with private members, getters and setters instead of plain structs")
+	rootCmd.Flags().StringVar(&getterPattern, "getter-pattern", codegen.DefaultGetterPattern, "Getter name with --classes; {name} is the member name, {Name} the same capitalized")
+	rootCmd.Flags().StringVar(&setterPattern, "setter-pattern", codegen.DefaultSetterPattern, "Setter name with --classes; {name} is the member name, {Name} the same capitalized")
+	rootCmd.Flags().BoolVar(&builders, "builders", false, "Generate builders.h with a fluent <Name>Builder per struct whose build() checks required members")
+	addParseFlags(rootCmd)
+	addTypeFlags(rootCmd)
 
 	// Deprecated flags (kept for compatibility but ignored)
 	rootCmd.Flags().BoolVar(&stringRef, "string-ref", false, "[DEPRECATED] String-ref flag is ignored")
@@ -69,98 +103,349 @@ func init() {
 	rootCmd.Flags().MarkHidden("overwrite")
 }
 
+// addParseFlags registers the flags that control input parsing and type
+// inference. They are shared by the generator and the explain command.
+func addParseFlags(c *cobra.Command) {
+	c.Flags().StringVarP(&inputFile, "input", "i", "", "Input JSON file (required)")
+	c.Flags().BoolVar(&camelCase, "camelcase", false, "Use camelCase for field names (default: snake_case)")
+	c.Flags().BoolVar(&merge, "merge", false, "Merge multiple JSON files (supports wildcards)")
+	c.Flags().BoolVar(&batch, "batch", false, "Generate one root struct per input file (supports wildcards), named after the file")
+	c.Flags().BoolVar(&strictMerge, "strict-merge", false, "Fail when merged samples disagree on the kind or representation of a field")
+	c.Flags().StringVar(&promotion, "promotion", "", "Policy for kinds that do not join when merging samples or array elements: widening (default), variant, error, last-wins")
+	c.Flags().IntVar(&jobs, "jobs", 0, "Number of input files parsed in parallel with --merge or --batch (0 = number of CPUs)")
+	c.Flags().BoolVar(&singularNames, "singular-names", false, "Name array item structs after the singular key (users -> User) instead of UsersItem")
+	c.Flags().StringToStringVar(&singularExcept, "singular-exception", nil, "Item struct name for a JSON key, overriding singularization (key=Name, repeatable)")
+	c.Flags().BoolVar(&detectUnions, "detect-unions", false, "Generate tagged unions for arrays of objects discriminated by a \"type\" or \"kind\" field")
+	c.Flags().StringSliceVar(&unionTags, "union-tag", nil, "Tag field name for tagged union detection (implies --detect-unions, repeatable)")
+	c.Flags().IntVar(&extractBases, "extract-bases", 0, "Move fields shared by at least N structs into common base structs (0 = off)")
+	c.Flags().StringSliceVar(&decimalFields, "decimal-fields", nil, "Map numbers whose key or JSON Pointer matches a pattern (e.g. price,*_amount) to an exact Decimal type")
+	c.Flags().BoolVar(&detectBase64, "detect-base64", false, "Map strings that look like base64 data to std::vector<uint8_t>")
+	c.Flags().BoolVar(&detectNumeric, "detect-numeric-strings", false, "Map strings holding numbers (\"42\", \"0.75\") to int64_t/double, written back as strings")
+	c.Flags().StringVar(&hintsFile, "hints", "", "JSON file with per-field type hints")
+	c.MarkFlagRequired("input")
+}
+
+// addTypeFlags registers the flags that choose the C++ types of members. They
+// are shared by the generator and the explain command.
+func addTypeFlags(c *cobra.Command) {
+	c.Flags().StringVarP(&parserBackend, "parser", "p", "rapidjson", "JSON parser backend (rapidjson, nlohmann, jsoncpp)")
+	c.Flags().BoolVar(&legacyCpp, "legacy-cpp", false, "Generate C++03 compatible code")
+	c.Flags().IntVar(&cppStandard, "std", 11, "Target C++ standard (11, 17, 20); --legacy-cpp selects C++03")
+	c.Flags().BoolVar(&optionalNull, "optional-null", false, "Generate Optional<T> for optional and nullable fields")
+	c.Flags().BoolVar(&nullable, "nullable", false, "Generate tri-state Nullable<T> (absent, null, value) for fields seen as null")
+}
+
 func run(cmd *cobra.Command, args []string) error {
+	allStructs, err := collectStructs()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := generatorConfig()
+	if err != nil {
+		return err
+	}
+
+	// Create adapter generator
+	gen := codegen.NewAdapterGenerator(cfg, outputDir)
+
+	// Type information
+	typeInfo := &types.TypeInfo{
+		Structs: allStructs,
+	}
+
+	// Generate all files
+	fmt.Printf("Generating code for %s parser...\n", parserBackend)
+	if err := gen.GenerateFiles(typeInfo); err != nil {
+		return fmt.Errorf("failed to generate files: %w", err)
+	}
+
+	// Print summary
+	fmt.Printf("\n✓ Generated successfully in: %s\n", outputDir)
+	fmt.Printf("  - types.h (parser-independent data structures)\n")
+	fmt.Printf("  - types_io.h (operator<< for debug printing)\n")
+	if builders {
+		fmt.Printf("  - builders.h (fluent builders)\n")
+	}
+	fmt.Printf("  - serializer_%s.h (serialization declarations)\n", parserBackend)
+	fmt.Printf("  - serializer_%s.cpp (serialization implementation)\n", parserBackend)
+	fmt.Printf("\nStructs: %d\n", len(allStructs))
+	fmt.Printf("Parser: %s\n", parserBackend)
+	if legacyCpp {
+		fmt.Printf("Mode: C++03 compatible (deprecated, use C++11+)\n")
+	} else {
+		fmt.Printf("Mode: Modern C++ (C++%d)\n", cppStandard)
+	}
+
+	return nil
+}
+
+// generatorConfig converts the generator flags into a codegen.Config
+func generatorConfig() (codegen.Config, error) {
+	// Convert parser backend string to ParserType
+	var parser codegen.ParserType
+	switch parserBackend {
+	case "rapidjson":
+		parser = codegen.ParserRapidJSON
+	case "nlohmann":
+		parser = codegen.ParserNlohmann
+	case "jsoncpp":
+		parser = codegen.ParserJsonCpp
+	default:
+		return codegen.Config{}, fmt.Errorf("unsupported parser: %s (choose: rapidjson, nlohmann, jsoncpp)", parserBackend)
+	}
+
+	switch cppStandard {
+	case 11, 17, 20:
+	default:
+		return codegen.Config{}, fmt.Errorf("unsupported C++ standard: %d (choose: 11, 17, 20)", cppStandard)
+	}
+
+	// The accessor patterns only name anything with --classes
+	if classes {
+		for _, pattern := range []string{getterPattern, setterPattern} {
+			if err := codegen.ValidateAccessorPattern(pattern); err != nil {
+				return codegen.Config{}, err
+			}
+		}
+	}
+
+	return codegen.Config{
+		Parser:         parser,
+		LegacyCPP:      legacyCpp,
+		CppStandard:    cppStandard,
+		Namespace:      namespace,
+		CamelCase:      camelCase,
+		OptionalNull:   optionalNull,
+		Nullable:       nullable,
+		Equality:       equality,
+		Ordering:       ordering,
+		Hash:           hash,
+		SampleDefaults: sampleDefaults,
+		Provenance:     provenance,
+		DocComments:    docComments,
+		Encapsulate:    classes,
+		GetterPattern:  getterPattern,
+		SetterPattern:  setterPattern,
+		Builders:       builders,
+	}, nil
+}
+
+// collectStructs parses the input file (or every file matched by the pattern
+// in --merge mode) and returns the merged struct model. Inference warnings
+// are printed to stderr.
+func collectStructs() ([]*types.Struct, error) {
+	if merge && batch {
+		return nil, fmt.Errorf("--merge and --batch cannot be used together")
+	}
+
 	// Check input file exists
-	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
-		return fmt.Errorf("input file does not exist: %s", inputFile)
+	if !merge && !batch {
+		if _, err := os.Stat(inputFile); os.IsNotExist(err) {
+			return nil, fmt.Errorf("input file does not exist: %s", inputFile)
+		}
+	}
+
+	var fieldHints *hints.Hints
+	if hintsFile != "" {
+		h, err := hints.Load(hintsFile)
+		if err != nil {
+			return nil, err
+		}
+		fieldHints = h
 	}
 
-	// Create JSON parser
-	p := parser.NewParser(legacyCpp, camelCase)
+	// --promotion takes precedence over the default of the hints file
+	policyName := promotion
+	if policyName == "" && fieldHints != nil {
+		policyName = fieldHints.Promotion
+	}
+	policy, err := types.PolicyByName(policyName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create JSON parsers; each goroutine of parseFiles gets its own
+	opts := parser.Options{
+		SingularNames:        singularNames,
+		SingularExceptions:   singularExcept,
+		DetectUnions:         detectUnions || len(unionTags) > 0,
+		UnionTags:            unionTags,
+		DecimalFields:        decimalFields,
+		DetectBase64:         detectBase64,
+		DetectNumericStrings: detectNumeric,
+		Hints:                fieldHints,
+		Promotion:            policy,
+	}
+	newParser := func() *parser.Parser {
+		return parser.NewParserWithOptions(legacyCpp, camelCase, opts)
+	}
 
 	// Collect type information
 	var allStructs []*types.Struct
+	var warnings []string
+	var conflicts []types.Conflict
+	var mergeErr error
 
-	if merge {
+	if merge || batch {
 		// Process multiple JSON files (supports wildcards)
 		files, err := filepath.Glob(inputFile)
 		if err != nil {
-			return fmt.Errorf("failed to glob input files: %w", err)
+			return nil, fmt.Errorf("failed to glob input files: %w", err)
 		}
 
 		if len(files) == 0 {
-			return fmt.Errorf("no files matched pattern: %s", inputFile)
+			return nil, fmt.Errorf("no files matched pattern: %s", inputFile)
 		}
+		sort.Strings(files)
 
-		fmt.Printf("Merging %d files...\n", len(files))
-		for _, file := range files {
-			structs, err := p.ParseFile(file)
-			if err != nil {
-				return fmt.Errorf("failed to parse %s: %w", file, err)
+		rootNames := make([]string, len(files))
+		roots := make(map[string]string)
+		for i, file := range files {
+			rootNames[i] = "Root"
+			if !batch {
+				continue
+			}
+			base := filepath.Base(file)
+			rootNames[i] = types.GenerateStructName(strings.TrimSuffix(base, filepath.Ext(base)))
+			if other, taken := roots[rootNames[i]]; taken {
+				return nil, fmt.Errorf("%s and %s both map to root struct %s", other, file, rootNames[i])
+			}
+			roots[rootNames[i]] = file
+		}
+
+		if merge {
+			fmt.Fprintf(os.Stderr, "Merging %d files...\n", len(files))
+		} else {
+			fmt.Fprintf(os.Stderr, "Combining %d files...\n", len(files))
+		}
+		parsed, parseWarnings, err := parseFiles(files, rootNames, newParser)
+		if err != nil {
+			return nil, err
+		}
+		warnings = parseWarnings
+
+		// Fold in file order so the output does not depend on scheduling
+		for i, structs := range parsed {
+			if merge {
+				var found []types.Conflict
+				var err error
+				allStructs, found, err = types.MergeTypesWithPolicy(allStructs, structs, policy)
+				conflicts = append(conflicts, found...)
+				if err != nil && mergeErr == nil {
+					mergeErr = fmt.Errorf("%s: %w", files[i], err)
+				}
+				continue
+			}
+			var renames map[string]string
+			allStructs, renames = types.CombineTypes(allStructs, structs, rootNames[i])
+			for _, old := range sortedKeys(renames) {
+				warnings = append(warnings, fmt.Sprintf("%s: struct %s renamed to %s (differs from %s of an earlier file)", files[i], old, renames[old], old))
 			}
-			allStructs = types.MergeTypes(allStructs, structs)
 		}
 	} else {
 		// Process single file
+		p := newParser()
 		structs, err := p.ParseFile(inputFile)
 		if err != nil {
-			return fmt.Errorf("failed to parse input file: %w", err)
+			return nil, fmt.Errorf("failed to parse input file: %w", err)
 		}
 		allStructs = structs
+		warnings = p.Warnings()
+	}
+
+	for _, w := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+
+	if merge {
+		printMergeReport(conflicts)
+		if mergeErr != nil {
+			return nil, mergeErr
+		}
+		if strictMerge && len(conflicts) > 0 {
+			return nil, fmt.Errorf("%d merge conflicts (--strict-merge)", len(conflicts))
+		}
 	}
 
 	if len(allStructs) == 0 {
-		return fmt.Errorf("no structs generated from input")
+		return nil, fmt.Errorf("no structs generated from input")
 	}
 
-	// Convert parser backend string to ParserType
-	var parser codegen.ParserType
-	switch parserBackend {
-	case "rapidjson":
-		parser = codegen.ParserRapidJSON
-	case "nlohmann":
-		parser = codegen.ParserNlohmann
-	case "jsoncpp":
-		parser = codegen.ParserJsonCpp
-	default:
-		return fmt.Errorf("unsupported parser: %s (choose: rapidjson, nlohmann, jsoncpp)", parserBackend)
+	if extractBases > 0 {
+		info := &types.TypeInfo{Structs: allStructs}
+		types.ExtractBaseStructs(info, extractBases)
+		allStructs = info.Structs
 	}
 
-	// Configure code generator
-	cfg := codegen.Config{
-		Parser:       parser,
-		LegacyCPP:    legacyCpp,
-		Namespace:    namespace,
-		CamelCase:    camelCase,
-		OptionalNull: optionalNull,
+	return allStructs, nil
+}
+
+// parseFiles parses files with up to --jobs parsers running in parallel and
+// returns the structs and warnings of each file in the order of files.
+func parseFiles(files []string, rootNames []string, newParser func() *parser.Parser) ([][]*types.Struct, []string, error) {
+	workers := jobs
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(files) {
+		workers = len(files)
 	}
 
-	// Create adapter generator
-	gen := codegen.NewAdapterGenerator(cfg, outputDir)
+	results := make([][]*types.Struct, len(files))
+	warnings := make([][]string, len(files))
+	errs := make([]error, len(files))
 
-	// Type information
-	typeInfo := &types.TypeInfo{
-		Structs: allStructs,
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := newParser()
+			for i := range next {
+				seen := len(p.Warnings())
+				results[i], errs[i] = p.ParseFileAs(files[i], rootNames[i])
+				warnings[i] = p.Warnings()[seen:]
+			}
+		}()
 	}
+	for i := range files {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
 
-	// Generate all files
-	fmt.Printf("Generating code for %s parser...\n", parserBackend)
-	if err := gen.GenerateFiles(typeInfo); err != nil {
-		return fmt.Errorf("failed to generate files: %w", err)
+	var all []string
+	for i, err := range errs {
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse %s: %w", files[i], err)
+		}
+		all = append(all, warnings[i]...)
 	}
+	return results, all, nil
+}
 
-	// Print summary
-	fmt.Printf("\n✓ Generated successfully in: %s\n", outputDir)
-	fmt.Printf("  - types.h (parser-independent data structures)\n")
-	fmt.Printf("  - serializer_%s.h (serialization declarations)\n", parserBackend)
-	fmt.Printf("  - serializer_%s.cpp (serialization implementation)\n", parserBackend)
-	fmt.Printf("\nStructs: %d\n", len(allStructs))
-	fmt.Printf("Parser: %s\n", parserBackend)
-	if legacyCpp {
-		fmt.Printf("Mode: C++03 compatible (deprecated, use C++11+)\n")
-	} else {
-		fmt.Printf("Mode: Modern C++ (C++11+)\n")
+// printMergeReport lists every disagreement between the merged samples and
+// how it was resolved, on stderr so that it does not mix with explain output.
+func printMergeReport(conflicts []types.Conflict) {
+	if len(conflicts) == 0 {
+		fmt.Fprintf(os.Stderr, "Merge report: no conflicts\n")
+		return
 	}
+	fmt.Fprintf(os.Stderr, "Merge report: %d conflicts\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "  %s.%s\n", c.Struct, c.Field)
+		fmt.Fprintf(os.Stderr, "      observed:   %s\n", c.Observed)
+		fmt.Fprintf(os.Stderr, "      resolution: %s\n", c.Resolution)
+	}
+}
 
-	return nil
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
@@ -8,17 +8,30 @@ import (
 	"json2cpp/internal/types"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // AdapterGenerator generates parser-agnostic C++ code with separate serializers
 type AdapterGenerator struct {
-	parser       ParserType
-	legacyCpp    bool
-	namespace    string
-	useCamelCase bool
-	optionalNull bool
-	outputDir    string
-	usedNames    map[string]int
+	parser         ParserType
+	legacyCpp      bool
+	cppStandard    int
+	namespace      string
+	useCamelCase   bool
+	optionalNull   bool
+	nullable       bool
+	equality       bool
+	ordering       bool
+	hash           bool
+	sampleDefaults bool
+	provenance     bool
+	docComments    bool
+	encapsulate    bool
+	builders       bool
+	getterPattern  string
+	setterPattern  string
+	outputDir      string
+	usedNames      map[string]int
 }
 
 // NewAdapterGenerator creates a new adapter-based code generator
@@ -27,18 +40,44 @@ func NewAdapterGenerator(cfg Config, outputDir string) *AdapterGenerator {
 	if parser == "" {
 		parser = ParserRapidJSON
 	}
+	cppStandard := cfg.CppStandard
+	if cfg.LegacyCPP {
+		cppStandard = 3
+	} else if cppStandard == 0 {
+		cppStandard = 11
+	}
+	getterPattern := cfg.GetterPattern
+	if getterPattern == "" {
+		getterPattern = DefaultGetterPattern
+	}
+	setterPattern := cfg.SetterPattern
+	if setterPattern == "" {
+		setterPattern = DefaultSetterPattern
+	}
 	return &AdapterGenerator{
-		parser:       parser,
-		legacyCpp:    cfg.LegacyCPP,
-		namespace:    cfg.Namespace,
-		useCamelCase: cfg.CamelCase,
-		optionalNull: cfg.OptionalNull,
-		outputDir:    outputDir,
-		usedNames:    make(map[string]int),
+		parser:         parser,
+		legacyCpp:      cfg.LegacyCPP,
+		cppStandard:    cppStandard,
+		namespace:      cfg.Namespace,
+		useCamelCase:   cfg.CamelCase,
+		optionalNull:   cfg.OptionalNull,
+		nullable:       cfg.Nullable,
+		equality:       cfg.Equality || cfg.Ordering,
+		ordering:       cfg.Ordering,
+		hash:           cfg.Hash,
+		sampleDefaults: cfg.SampleDefaults,
+		provenance:     cfg.Provenance,
+		docComments:    cfg.DocComments,
+		encapsulate:    cfg.Encapsulate,
+		builders:       cfg.Builders,
+		getterPattern:  getterPattern,
+		setterPattern:  setterPattern,
+		outputDir:      outputDir,
+		usedNames:      make(map[string]int),
 	}
 }
 
-// GenerateFiles generates all necessary files (types.h and parser-specific serializer)
+// GenerateFiles generates all necessary files (types.h, types_io.h, builders.h when enabled and parser-specific serializer)
 func (g *AdapterGenerator) GenerateFiles(info *types.TypeInfo) error {
 	// Sort structs by dependencies
 	types.SortStructs(info.Structs)
@@ -57,6 +96,22 @@ func (g *AdapterGenerator) GenerateFiles(info *types.TypeInfo) error {
 		return err
 	}
 
+	// Generate types_io.h (debug printing, parser-independent)
+	if err := g.writeFile("types_io.h", g.generateTypesIO(info)); err != nil {
+		return err
+	}
+
+	// Generate builders.h (fluent builders, parser-independent)
+	if g.builders {
+		builders, err := g.generateBuilders(info)
+		if err != nil {
+			return fmt.Errorf("failed to generate builders.h: %w", err)
+		}
+		if err := g.writeFile("builders.h", builders); err != nil {
+			return err
+		}
+	}
+
 	// Generate parser-specific serializer header
 	serializerHeaderName := fmt.Sprintf("serializer_%s.h", g.parser)
 	serializerHeader, err := g.generateSerializerHeader(info)
@@ -80,7 +135,6 @@ func (g *AdapterGenerator) GenerateFiles(info *types.TypeInfo) error {
 	return nil
 }
 
-
 // generateTypes generates the types.h file with pure data structures
 func (g *AdapterGenerator) generateTypes(info *types.TypeInfo) (string, error) {
 	var buf bytes.Buffer
@@ -99,6 +153,31 @@ func (g *AdapterGenerator) generateTypes(info *types.TypeInfo) (string, error) {
 	} else {
 		buf.WriteString("#include <cstdint>\n")
 	}
+	if g.useVariant() && (hasUnions(info) || usesVariants(info)) {
+		buf.WriteString("#include <variant>\n")
+	}
+	needsOptional := g.usesOptional(info)
+	if needsOptional && g.cppStandard >= 17 {
+		buf.WriteString("#include <optional>\n")
+	}
+	if g.ordering && g.cppStandard >= 20 {
+		buf.WriteString("#include <compare>\n")
+	}
+	if g.hash {
+		buf.WriteString("#include <cstddef>\n")
+		buf.WriteString("#include <cstring>\n")
+		if !g.legacyCpp {
+			buf.WriteString("#include <functional>\n")
+		}
+	}
+	if g.encapsulate && !g.legacyCpp {
+		buf.WriteString("#include <utility>\n")
+	}
+	needsDecimal := usesFormat(info, types.FormatDecimal)
+	if needsDecimal {
+		buf.WriteString("#include <cstdio>\n")
+		buf.WriteString("#include <cstdlib>\n")
+	}
 
 	buf.WriteString("\n")
 
@@ -107,6 +186,30 @@ func (g *AdapterGenerator) generateTypes(info *types.TypeInfo) (string, error) {
 		buf.WriteString(fmt.Sprintf("namespace %s {\n\n", g.namespace))
 	}
 
+	if needsDecimal {
+		buf.WriteString(decimalTypeDefinition)
+		buf.WriteString("\n")
+		g.writeComparisonHelpers(&buf, decimalEquality, decimalOrdering)
+	}
+	if needsOptional {
+		if g.cppStandard >= 17 {
+			buf.WriteString(optionalAlias)
+			buf.WriteString("\n")
+		} else {
+			buf.WriteString(optionalDefinition)
+			buf.WriteString("\n")
+			g.writeComparisonHelpers(&buf, optionalEquality, optionalOrdering)
+		}
+	}
+	if g.usesNullable(info) {
+		buf.WriteString(nullableDefinition)
+		buf.WriteString("\n")
+		g.writeComparisonHelpers(&buf, nullableEquality, nullableOrdering)
+	}
+	if g.hash {
+		g.writeHashHelpers(&buf, info)
+	}
+
 	// Struct definitions (reverse order - dependencies first)
 	for i := len(info.Structs) - 1; i >= 0; i-- {
 		s := info.Structs[i]
@@ -117,7 +220,14 @@ func (g *AdapterGenerator) generateTypes(info *types.TypeInfo) (string, error) {
 		if err != nil {
 			return "", err
 		}
+		if g.docComments {
+			buf.WriteString(structDocComment(s, derivedStructs(info, s)))
+		}
 		buf.WriteString(structCode)
+		if g.hash {
+			buf.WriteString("\n")
+			buf.WriteString(g.generateHashValue(s))
+		}
 	}
 
 	// Namespace end
@@ -127,6 +237,12 @@ func (g *AdapterGenerator) generateTypes(info *types.TypeInfo) (string, error) {
 		buf.WriteString("\n")
 	}
 
+	// std::hash specializations (C++11); C++03 code gets hash_value only
+	if g.hash && !g.legacyCpp {
+		buf.WriteString("\n")
+		buf.WriteString(g.generateStdHash(info))
+	}
+
 	buf.WriteString("\n#endif // JSON2CPP_TYPES_H\n")
 
 	return buf.String(), nil
@@ -139,7 +255,18 @@ func (g *AdapterGenerator) generateStruct(s *types.Struct) (string, error) {
 	// Reset usedNames for each struct
 	g.usedNames = make(map[string]int)
 
-	buf.WriteString(fmt.Sprintf("struct %s {\n", s.Name))
+	if s.Union != nil {
+		return g.withComparisons(s, g.generateUnionStruct(s)), nil
+	}
+	if g.encapsulate {
+		return g.generateClass(s)
+	}
+
+	if s.Base != nil {
+		buf.WriteString(fmt.Sprintf("struct %s : public %s {\n", s.Name, s.Base.Name))
+	} else {
+		buf.WriteString(fmt.Sprintf("struct %s {\n", s.Name))
+	}
 
 	// Member variables
 	for _, f := range s.Fields {
@@ -147,42 +274,129 @@ func (g *AdapterGenerator) generateStruct(s *types.Struct) (string, error) {
 		if err != nil {
 			return "", err
 		}
+		buf.WriteString(g.memberComments(f))
 		buf.WriteString("    " + member + "\n")
 	}
 
 	// Default constructor for C++03 compatibility
 	if g.legacyCpp {
-		buf.WriteString("\n    ")
-		buf.WriteString(s.Name)
-		buf.WriteString("()")
-
-		// Initialize POD types
-		needsInit := false
-		var initList bytes.Buffer
-		for _, f := range s.Fields {
-			if g.needsDefaultInit(f) {
-				if needsInit {
-					initList.WriteString(", ")
-				} else {
-					initList.WriteString(" : ")
-					needsInit = true
-				}
-				fieldName := g.getFieldName(f.Name)
-				initList.WriteString(fieldName)
-				initList.WriteString("(")
-				initList.WriteString(g.getDefaultInitValue(f))
-				initList.WriteString(")")
+		buf.WriteString("\n")
+		buf.WriteString(g.generateDefaultConstructor(s))
+	}
+
+	buf.WriteString("};\n")
+
+	return g.withComparisons(s, buf.String()), nil
+}
+
+// generateDefaultConstructor generates the C++03 default constructor, which
+// initializes the members that C++11 gives default member initializers
+func (g *AdapterGenerator) generateDefaultConstructor(s *types.Struct) string {
+	var buf bytes.Buffer
+	buf.WriteString("    ")
+	buf.WriteString(s.Name)
+	buf.WriteString("()")
+
+	// Initialize POD types
+	needsInit := false
+	var initList bytes.Buffer
+	for _, f := range s.Fields {
+		if g.needsDefaultInit(f) {
+			if needsInit {
+				initList.WriteString(", ")
+			} else {
+				initList.WriteString(" : ")
+				needsInit = true
 			}
+			initList.WriteString(g.memberName(f))
+			initList.WriteString("(")
+			initList.WriteString(g.getDefaultInitValue(f))
+			initList.WriteString(")")
 		}
-		if needsInit {
-			buf.WriteString(initList.String())
+	}
+	if needsInit {
+		buf.WriteString(initList.String())
+	}
+	buf.WriteString(" {}\n")
+	return buf.String()
+}
+
+// withComparisons adds the comparison operators of a struct to its
+// definition with --equality: defaulted members in C++20, hand-written free
+// functions after the struct otherwise.
+func (g *AdapterGenerator) withComparisons(s *types.Struct, definition string) string {
+	switch {
+	case !g.equality:
+		return definition
+	case g.cppStandard >= 20 && g.encapsulate && s.Union == nil:
+		// declared in the public section by generateClass
+		return definition
+	case g.cppStandard >= 20:
+		body := strings.TrimSuffix(definition, "};\n")
+		return body + "\n" + g.defaultedComparisons(s.Name) + "};\n"
+	default:
+		return definition + "\n" + g.generateComparisonOperators(s)
+	}
+}
+
+// useVariant reports whether tagged unions are represented with std::variant
+func (g *AdapterGenerator) useVariant() bool {
+	return g.cppStandard >= 17
+}
+
+// hasUnions reports whether any struct is a tagged union wrapper
+func hasUnions(info *types.TypeInfo) bool {
+	for _, s := range info.Structs {
+		if s.Union != nil {
+			return true
 		}
-		buf.WriteString(" {}\n")
 	}
+	return false
+}
+
+// generateUnionStruct generates the wrapper of a tagged union: a std::variant
+// of the variant structs in C++17, otherwise the tag value plus one member
+// per variant.
+func (g *AdapterGenerator) generateUnionStruct(s *types.Struct) string {
+	var buf bytes.Buffer
 
+	buf.WriteString(fmt.Sprintf("// Tagged union on \"%s\"\n", commentText(s.Union.Tag)))
+	buf.WriteString(fmt.Sprintf("struct %s {\n", s.Name))
+	if g.useVariant() {
+		names := make([]string, 0, len(s.Union.Variants))
+		for _, v := range s.Union.Variants {
+			names = append(names, v.Type.Name)
+		}
+		buf.WriteString(fmt.Sprintf("    std::variant<%s> value;\n", strings.Join(names, ", ")))
+	} else {
+		tagMember, members := g.unionMembers(s)
+		buf.WriteString(fmt.Sprintf("    std::string %s;\n", tagMember))
+		for i, v := range s.Union.Variants {
+			buf.WriteString(fmt.Sprintf("    %s %s;\n", v.Type.Name, members[i]))
+		}
+	}
 	buf.WriteString("};\n")
 
-	return buf.String(), nil
+	return buf.String()
+}
+
+// unionMembers returns the members of a tagged union wrapper before C++17:
+// the tag member, and one member per variant named after its tag value. Tag
+// values mapping to a name already taken get a numeric suffix.
+func (g *AdapterGenerator) unionMembers(s *types.Struct) (string, []string) {
+	tagMember := g.getFieldName(s.Union.Tag)
+	used := map[string]bool{tagMember: true}
+	members := make([]string, 0, len(s.Union.Variants))
+	for _, v := range s.Union.Variants {
+		name := g.getFieldName(v.Value)
+		candidate := name
+		for i := 2; used[candidate]; i++ {
+			candidate = fmt.Sprintf("%s%d", name, i)
+		}
+		used[candidate] = true
+		members = append(members, candidate)
+	}
+	return tagMember, members
 }
 
 // generateMember generates a member variable declaration
@@ -192,12 +406,60 @@ func (g *AdapterGenerator) generateMember(f *types.Field) (string, error) {
 		return "", err
 	}
 
-	fieldName := g.getFieldName(f.Name)
+	fieldName := g.memberName(f)
+	// C++03 initializes members in the constructor instead
+	if init := g.getDefaultInitValue(f); init != "" && !g.legacyCpp {
+		return fmt.Sprintf("%s %s = %s;", memberType, fieldName, init), nil
+	}
 	return fmt.Sprintf("%s %s;", memberType, fieldName), nil
 }
 
-// getCppType returns the C++ type for a field
+// CppType returns the C++ member type the generator emits for a field
+func (g *AdapterGenerator) CppType(f *types.Field) (string, error) {
+	return g.getCppType(f)
+}
+
+// getCppType returns the C++ type for a field, wrapped in Optional<T> for
+// optional fields with --optional-null and in Nullable<T> for fields seen as
+// null with --nullable
 func (g *AdapterGenerator) getCppType(f *types.Field) (string, error) {
+	valueType, err := g.valueCppType(f)
+	if err != nil {
+		return "", err
+	}
+	wrapper := ""
+	switch {
+	case g.isNullableMember(f):
+		wrapper = "Nullable"
+	case g.isOptionalMember(f):
+		wrapper = "Optional"
+	default:
+		return valueType, nil
+	}
+	if g.legacyCpp && strings.HasSuffix(valueType, ">") {
+		return fmt.Sprintf("%s<%s >", wrapper, valueType), nil
+	}
+	return fmt.Sprintf("%s<%s>", wrapper, valueType), nil
+}
+
+// valueCppType returns the C++ type for the value of a field
+func (g *AdapterGenerator) valueCppType(f *types.Field) (string, error) {
+	if isVariant(f) {
+		return g.variantCppType(f)
+	}
+	switch f.Format {
+	case types.FormatDecimal:
+		return "Decimal", nil
+	case types.FormatBase64, types.FormatBase64URL:
+		return "std::vector<uint8_t>", nil
+	case types.FormatIntString:
+		return "int64_t", nil
+	case types.FormatFloatString:
+		return "double", nil
+	}
+	if f.CppType != "" {
+		return f.CppType, nil
+	}
 	switch f.Type {
 	case types.JSONNull:
 		// Null types are typically represented as bool or skipped
@@ -214,6 +476,9 @@ func (g *AdapterGenerator) getCppType(f *types.Field) (string, error) {
 		if f.NestedType != nil {
 			return fmt.Sprintf("std::vector<%s>", f.NestedType.Name), nil
 		}
+		if hasNullableElems(f) {
+			return g.nullableElemsCppType(f), nil
+		}
 		switch f.ElemType {
 		case types.JSONString:
 			return "std::vector<std::string>", nil
@@ -236,23 +501,32 @@ func (g *AdapterGenerator) getCppType(f *types.Field) (string, error) {
 	}
 }
 
-// needsDefaultInit checks if a field needs default initialization in C++03
+// needsDefaultInit checks if a field needs default initialization
 func (g *AdapterGenerator) needsDefaultInit(f *types.Field) bool {
-	return f.Type == types.JSONBool || f.Type == types.JSONInt || f.Type == types.JSONFloat
+	return g.getDefaultInitValue(f) != ""
 }
 
-// getDefaultInitValue returns the default initialization value for a field
+// getDefaultInitValue returns the default initialization value for a field:
+// its hint default, else with --sample-defaults its first sample value, else
+// zero for numbers and bools. Values that do not fit the member are skipped.
 func (g *AdapterGenerator) getDefaultInitValue(f *types.Field) string {
-	switch f.Type {
-	case types.JSONBool:
-		return "false"
-	case types.JSONInt:
-		return "0"
-	case types.JSONFloat:
-		return "0.0"
-	default:
+	kind := g.defaultKindOf(f)
+	if kind == defaultNone {
 		return ""
 	}
+	var candidates []string
+	if f.Default != "" {
+		candidates = append(candidates, f.Default)
+	}
+	if g.sampleDefaults && len(f.Examples) > 0 {
+		candidates = append(candidates, f.Examples[0])
+	}
+	for _, text := range candidates {
+		if literal, ok := defaultLiteral(kind, intBits(f.CppType), text); ok {
+			return literal
+		}
+	}
+	return zeroLiteral(kind)
 }
 
 // getFieldName sanitizes and returns a C++ field name
@@ -367,19 +641,42 @@ func (g *AdapterGenerator) generateSerializerImpl(info *types.TypeInfo) (string,
 	// Header
 	buf.WriteString("// Auto-generated by json2cpp\n")
 	buf.WriteString(fmt.Sprintf("// %s serialization implementation\n\n", g.parser))
-	buf.WriteString(fmt.Sprintf("#include \"serializer_%s.h\"\n\n", g.parser))
+	buf.WriteString(fmt.Sprintf("#include \"serializer_%s.h\"\n", g.parser))
+	if usesBase64(info) || usesNumericStrings(info) {
+		buf.WriteString("#include <stdexcept>\n")
+	}
+	if usesNumericStrings(info) {
+		buf.WriteString("#include <cstdio>\n")
+		buf.WriteString("#include <cstdlib>\n")
+		buf.WriteString("#include <cerrno>\n")
+	}
+	buf.WriteString("\n")
 
 	// Namespace start
 	if g.namespace != "" {
 		buf.WriteString(fmt.Sprintf("namespace %s {\n\n", g.namespace))
 	}
 
+	if g.usesOptional(info) {
+		buf.WriteString(engageHelper)
+		buf.WriteString("\n")
+	}
+	if g.usesNullable(info) {
+		buf.WriteString(nullableEngageHelper)
+		buf.WriteString("\n")
+	}
+	if usesBase64(info) {
+		buf.WriteString(base64Helpers)
+		buf.WriteString("\n")
+	}
+	if usesNumericStrings(info) {
+		buf.WriteString(numericStringHelpers)
+		buf.WriteString("\n")
+	}
+
 	// Generate deserialize and serialize functions for each struct
+	var functions []string
 	for i, s := range info.Structs {
-		if i > 0 {
-			buf.WriteString("\n")
-		}
-
 		// Reset usedNames for each struct
 		g.usedNames = make(map[string]int)
 
@@ -388,17 +685,28 @@ func (g *AdapterGenerator) generateSerializerImpl(info *types.TypeInfo) (string,
 		if err != nil {
 			return "", err
 		}
-		buf.WriteString(deserializeCode)
-		buf.WriteString("\n\n")
 
 		// Serialize function
 		serializeCode, err := g.generateSerializeFunction(s)
 		if err != nil {
 			return "", err
 		}
+
+		if g.encapsulate {
+			functions = append(functions, deserializeCode, serializeCode)
+			continue
+		}
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(deserializeCode)
+		buf.WriteString("\n\n")
 		buf.WriteString(serializeCode)
 		buf.WriteString("\n")
 	}
+	if g.encapsulate {
+		buf.WriteString(g.generateSerializerAccess(info.Structs, functions))
+	}
 
 	// Namespace end
 	if g.namespace != "" {
@@ -424,6 +732,13 @@ func (g *AdapterGenerator) generateDeserializeFunction(s *types.Struct) (string,
 		buf.WriteString(fmt.Sprintf("void Deserialize%s(%s& obj, const Json::Value& json) {\n", s.Name, s.Name))
 	}
 
+	if s.Union != nil {
+		buf.WriteString(g.generateUnionDeserializeBody(s))
+	}
+	if s.Base != nil {
+		buf.WriteString(fmt.Sprintf("    Deserialize%s(obj, json);\n", s.Base.Name))
+	}
+
 	for _, f := range s.Fields {
 		code, err := g.generateDeserializeField(f)
 		if err != nil {
@@ -437,8 +752,156 @@ func (g *AdapterGenerator) generateDeserializeFunction(s *types.Struct) (string,
 	return buf.String(), nil
 }
 
+// generateUnionDeserializeBody reads the tag value and deserializes the whole
+// object into the variant it selects. Unknown tag values are ignored.
+func (g *AdapterGenerator) generateUnionDeserializeBody(s *types.Struct) string {
+	var buf bytes.Buffer
+	tag := s.Union.Tag
+
+	buf.WriteString(fmt.Sprintf("    if (!(%s)) {\n", g.stringMemberCheck(tag)))
+	buf.WriteString("        return;\n")
+	buf.WriteString("    }\n")
+	buf.WriteString(fmt.Sprintf("    const std::string tag = %s;\n", g.stringMemberValue(tag)))
+	tagMember, members := g.unionMembers(s)
+	if !g.useVariant() {
+		buf.WriteString(fmt.Sprintf("    obj.%s = tag;\n", tagMember))
+	}
+
+	for i, v := range s.Union.Variants {
+		if i == 0 {
+			buf.WriteString(fmt.Sprintf("    if (tag == %s) {\n", cppStringLiteral(v.Value)))
+		} else {
+			buf.WriteString(fmt.Sprintf("    } else if (tag == %s) {\n", cppStringLiteral(v.Value)))
+		}
+		if g.useVariant() {
+			buf.WriteString(fmt.Sprintf("        %s value;\n", v.Type.Name))
+			buf.WriteString(fmt.Sprintf("        Deserialize%s(value, json);\n", v.Type.Name))
+			buf.WriteString("        obj.value = value;\n")
+		} else {
+			buf.WriteString(fmt.Sprintf("        Deserialize%s(obj.%s, json);\n", v.Type.Name, members[i]))
+		}
+	}
+	if len(s.Union.Variants) > 0 {
+		buf.WriteString("    }\n")
+	}
+
+	return buf.String()
+}
+
+// generateUnionSerializeBody serializes the active variant, selected by the
+// variant index in C++17 and by the stored tag value otherwise.
+func (g *AdapterGenerator) generateUnionSerializeBody(s *types.Struct) string {
+	var buf bytes.Buffer
+
+	if g.useVariant() {
+		buf.WriteString("    switch (obj.value.index()) {\n")
+		for i, v := range s.Union.Variants {
+			buf.WriteString(fmt.Sprintf("    case %d: // %s == %s\n", i, commentText(s.Union.Tag), cppStringLiteral(v.Value)))
+			buf.WriteString(fmt.Sprintf("        %s\n", g.serializeCall(v.Type.Name, fmt.Sprintf("std::get<%d>(obj.value)", i))))
+			buf.WriteString("        break;\n")
+		}
+		buf.WriteString("    }\n")
+		return buf.String()
+	}
+
+	tagMember, members := g.unionMembers(s)
+	for i, v := range s.Union.Variants {
+		if i == 0 {
+			buf.WriteString(fmt.Sprintf("    if (obj.%s == %s) {\n", tagMember, cppStringLiteral(v.Value)))
+		} else {
+			buf.WriteString(fmt.Sprintf("    } else if (obj.%s == %s) {\n", tagMember, cppStringLiteral(v.Value)))
+		}
+		buf.WriteString(fmt.Sprintf("        %s\n", g.serializeCall(v.Type.Name, "obj."+members[i])))
+	}
+	if len(s.Union.Variants) > 0 {
+		buf.WriteString("    }\n")
+	}
+
+	return buf.String()
+}
+
+// stringMemberCheck returns the condition testing that json has a string member
+func (g *AdapterGenerator) stringMemberCheck(jsonName string) string {
+	switch g.parser {
+	case ParserNlohmann:
+		return fmt.Sprintf("json.contains(\"%s\") && json[\"%s\"].is_string()", jsonName, jsonName)
+	case ParserJsonCpp:
+		return fmt.Sprintf("json.isMember(\"%s\") && json[\"%s\"].isString()", jsonName, jsonName)
+	default:
+		return fmt.Sprintf("json.HasMember(\"%s\") && json[\"%s\"].IsString()", jsonName, jsonName)
+	}
+}
+
+// stringMemberValue returns the expression reading a string member of json
+func (g *AdapterGenerator) stringMemberValue(jsonName string) string {
+	switch g.parser {
+	case ParserNlohmann:
+		return fmt.Sprintf("json[\"%s\"].get<std::string>()", jsonName)
+	case ParserJsonCpp:
+		return fmt.Sprintf("json[\"%s\"].asString()", jsonName)
+	default:
+		return fmt.Sprintf("json[\"%s\"].GetString()", jsonName)
+	}
+}
+
+// scalarType returns the annotated C++ type of a number field, or the
+// inferred one
+func scalarType(f *types.Field, inferred string) string {
+	if f.CppType != "" {
+		return f.CppType
+	}
+	return inferred
+}
+
+// isUnsignedScalar reports whether the annotated C++ type of a field is an
+// unsigned integer, read as uint64_t so that values above INT64_MAX fit
+func isUnsignedScalar(f *types.Field) bool {
+	return strings.HasPrefix(f.CppType, "uint")
+}
+
+// castScalar converts a number read as int64_t or double to the annotated
+// C++ type of the field, if any
+func castScalar(f *types.Field, expr string) string {
+	if f.CppType == "" {
+		return expr
+	}
+	return fmt.Sprintf("static_cast<%s>(%s)", f.CppType, expr)
+}
+
+// serializeCall returns the statement serializing value into json itself
+func (g *AdapterGenerator) serializeCall(typeName, value string) string {
+	if g.parser == ParserRapidJSON {
+		return fmt.Sprintf("Serialize%s(%s, json, allocator);", typeName, value)
+	}
+	return fmt.Sprintf("Serialize%s(%s, json);", typeName, value)
+}
+
 // generateDeserializeField generates parser-specific deserialization code for a single field
 func (g *AdapterGenerator) generateDeserializeField(f *types.Field) (string, error) {
+	code, err := g.generateDeserializeValue(f)
+	switch {
+	case err != nil:
+		return "", err
+	case g.isNullableMember(f):
+		return g.wrapNullableDeserialize(f, code), nil
+	case g.isOptionalMember(f):
+		return g.wrapOptionalDeserialize(f, code), nil
+	default:
+		return code, nil
+	}
+}
+
+// generateDeserializeValue generates the code reading the value of a field
+func (g *AdapterGenerator) generateDeserializeValue(f *types.Field) (string, error) {
+	if isVariant(f) {
+		return g.generateDeserializeVariant(f)
+	}
+	if f.Format != types.FormatDefault {
+		return g.generateDeserializeFormatted(f)
+	}
+	if hasNullableElems(f) {
+		return g.generateDeserializeNullableElems(f)
+	}
 	switch g.parser {
 	case ParserRapidJSON:
 		return g.generateDeserializeFieldRapidJSON(f)
@@ -454,7 +917,7 @@ func (g *AdapterGenerator) generateDeserializeField(f *types.Field) (string, err
 // generateDeserializeFieldRapidJSON generates RapidJSON deserialization code
 func (g *AdapterGenerator) generateDeserializeFieldRapidJSON(f *types.Field) (string, error) {
 	var buf bytes.Buffer
-	fieldName := g.getFieldName(f.Name)
+	member := g.targetRef(f)
 	jsonName := f.JSONName
 
 	switch f.Type {
@@ -463,62 +926,68 @@ func (g *AdapterGenerator) generateDeserializeFieldRapidJSON(f *types.Field) (st
 
 	case types.JSONBool:
 		buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsBool()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].GetBool();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].GetBool();\n", member, jsonName))
 		buf.WriteString("    }\n")
 
 	case types.JSONInt:
-		buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\")) {\n", jsonName))
-		buf.WriteString(fmt.Sprintf("        if (json[\"%s\"].IsInt64()) {\n", jsonName))
-		buf.WriteString(fmt.Sprintf("            obj.%s = json[\"%s\"].GetInt64();\n", fieldName, jsonName))
-		buf.WriteString(fmt.Sprintf("        } else if (json[\"%s\"].IsInt()) {\n", jsonName))
-		buf.WriteString(fmt.Sprintf("            obj.%s = static_cast<int64_t>(json[\"%s\"].GetInt());\n", fieldName, jsonName))
-		buf.WriteString("        }\n")
-		buf.WriteString("    }\n")
+		if isUnsignedScalar(f) {
+			buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsUint64()) {\n", jsonName, jsonName))
+			buf.WriteString(fmt.Sprintf("        %s = %s;\n", member, castScalar(f, fmt.Sprintf("json[\"%s\"].GetUint64()", jsonName))))
+			buf.WriteString("    }\n")
+		} else {
+			buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\")) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("        if (json[\"%s\"].IsInt64()) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("            %s = %s;\n", member, castScalar(f, fmt.Sprintf("json[\"%s\"].GetInt64()", jsonName))))
+			buf.WriteString(fmt.Sprintf("        } else if (json[\"%s\"].IsInt()) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("            %s = static_cast<%s>(json[\"%s\"].GetInt());\n", member, scalarType(f, "int64_t"), jsonName))
+			buf.WriteString("        }\n")
+			buf.WriteString("    }\n")
+		}
 
 	case types.JSONFloat:
-		buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsDouble()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].GetDouble();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsNumber()) {\n", jsonName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = %s;\n", member, castScalar(f, fmt.Sprintf("json[\"%s\"].GetDouble()", jsonName))))
 		buf.WriteString("    }\n")
 
 	case types.JSONString:
 		buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsString()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].GetString();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].GetString();\n", member, jsonName))
 		buf.WriteString("    }\n")
 
 	case types.JSONArray:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsArray()) {\n", jsonName, jsonName))
 			buf.WriteString(fmt.Sprintf("        const rapidjson::Value& arr = json[\"%s\"];\n", jsonName))
-			buf.WriteString(fmt.Sprintf("        obj.%s.clear();\n", fieldName))
+			buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
 			buf.WriteString("        for (rapidjson::SizeType i = 0; i < arr.Size(); ++i) {\n")
 			buf.WriteString(fmt.Sprintf("            %s item;\n", f.NestedType.Name))
 			buf.WriteString(fmt.Sprintf("            Deserialize%s(item, arr[i]);\n", f.NestedType.Name))
-			buf.WriteString(fmt.Sprintf("            obj.%s.push_back(item);\n", fieldName))
+			buf.WriteString(fmt.Sprintf("            %s.push_back(item);\n", member))
 			buf.WriteString("        }\n")
 			buf.WriteString("    }\n")
 		} else {
 			buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsArray()) {\n", jsonName, jsonName))
 			buf.WriteString(fmt.Sprintf("        const rapidjson::Value& arr = json[\"%s\"];\n", jsonName))
-			buf.WriteString(fmt.Sprintf("        obj.%s.clear();\n", fieldName))
+			buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
 			buf.WriteString("        for (rapidjson::SizeType i = 0; i < arr.Size(); ++i) {\n")
 			switch f.ElemType {
 			case types.JSONString:
 				buf.WriteString("            if (arr[i].IsString()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(arr[i].GetString());\n", fieldName))
+				buf.WriteString(fmt.Sprintf("                %s.push_back(arr[i].GetString());\n", member))
 				buf.WriteString("            }\n")
 			case types.JSONInt:
 				buf.WriteString("            if (arr[i].IsInt64()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(arr[i].GetInt64());\n", fieldName))
+				buf.WriteString(fmt.Sprintf("                %s.push_back(arr[i].GetInt64());\n", member))
 				buf.WriteString("            } else if (arr[i].IsInt()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(static_cast<int64_t>(arr[i].GetInt()));\n", fieldName))
+				buf.WriteString(fmt.Sprintf("                %s.push_back(static_cast<int64_t>(arr[i].GetInt()));\n", member))
 				buf.WriteString("            }\n")
 			case types.JSONFloat:
-				buf.WriteString("            if (arr[i].IsDouble()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(arr[i].GetDouble());\n", fieldName))
+				buf.WriteString("            if (arr[i].IsNumber()) {\n")
+				buf.WriteString(fmt.Sprintf("                %s.push_back(arr[i].GetDouble());\n", member))
 				buf.WriteString("            }\n")
 			case types.JSONBool:
 				buf.WriteString("            if (arr[i].IsBool()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(arr[i].GetBool());\n", fieldName))
+				buf.WriteString(fmt.Sprintf("                %s.push_back(arr[i].GetBool());\n", member))
 				buf.WriteString("            }\n")
 			}
 			buf.WriteString("        }\n")
@@ -528,7 +997,7 @@ func (g *AdapterGenerator) generateDeserializeFieldRapidJSON(f *types.Field) (st
 	case types.JSONObject:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsObject()) {\n", jsonName, jsonName))
-			buf.WriteString(fmt.Sprintf("        Deserialize%s(obj.%s, json[\"%s\"]);\n", f.NestedType.Name, fieldName, jsonName))
+			buf.WriteString(fmt.Sprintf("        Deserialize%s(%s, json[\"%s\"]);\n", f.NestedType.Name, member, jsonName))
 			buf.WriteString("    }\n")
 		}
 
@@ -542,7 +1011,7 @@ func (g *AdapterGenerator) generateDeserializeFieldRapidJSON(f *types.Field) (st
 // generateDeserializeFieldNlohmann generates nlohmann/json deserialization code
 func (g *AdapterGenerator) generateDeserializeFieldNlohmann(f *types.Field) (string, error) {
 	var buf bytes.Buffer
-	fieldName := g.getFieldName(f.Name)
+	member := g.targetRef(f)
 	jsonName := f.JSONName
 
 	switch f.Type {
@@ -551,37 +1020,37 @@ func (g *AdapterGenerator) generateDeserializeFieldNlohmann(f *types.Field) (str
 
 	case types.JSONBool:
 		buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\") && json[\"%s\"].is_boolean()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].get<bool>();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].get<bool>();\n", member, jsonName))
 		buf.WriteString("    }\n")
 
 	case types.JSONInt:
 		buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\") && json[\"%s\"].is_number_integer()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].get<int64_t>();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].get<%s>();\n", member, jsonName, scalarType(f, "int64_t")))
 		buf.WriteString("    }\n")
 
 	case types.JSONFloat:
 		buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\") && json[\"%s\"].is_number()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].get<double>();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].get<%s>();\n", member, jsonName, scalarType(f, "double")))
 		buf.WriteString("    }\n")
 
 	case types.JSONString:
 		buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\") && json[\"%s\"].is_string()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].get<std::string>();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].get<std::string>();\n", member, jsonName))
 		buf.WriteString("    }\n")
 
 	case types.JSONArray:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\") && json[\"%s\"].is_array()) {\n", jsonName, jsonName))
-			buf.WriteString(fmt.Sprintf("        obj.%s.clear();\n", fieldName))
+			buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
 			buf.WriteString(fmt.Sprintf("        for (const auto& elem : json[\"%s\"]) {\n", jsonName))
 			buf.WriteString(fmt.Sprintf("            %s item;\n", f.NestedType.Name))
 			buf.WriteString(fmt.Sprintf("            Deserialize%s(item, elem);\n", f.NestedType.Name))
-			buf.WriteString(fmt.Sprintf("            obj.%s.push_back(item);\n", fieldName))
+			buf.WriteString(fmt.Sprintf("            %s.push_back(item);\n", member))
 			buf.WriteString("        }\n")
 			buf.WriteString("    }\n")
 		} else {
 			buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\") && json[\"%s\"].is_array()) {\n", jsonName, jsonName))
-			buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].get<", fieldName, jsonName))
+			buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].get<", member, jsonName))
 			switch f.ElemType {
 			case types.JSONString:
 				buf.WriteString("std::vector<std::string>")
@@ -599,7 +1068,7 @@ func (g *AdapterGenerator) generateDeserializeFieldNlohmann(f *types.Field) (str
 	case types.JSONObject:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\") && json[\"%s\"].is_object()) {\n", jsonName, jsonName))
-			buf.WriteString(fmt.Sprintf("        Deserialize%s(obj.%s, json[\"%s\"]);\n", f.NestedType.Name, fieldName, jsonName))
+			buf.WriteString(fmt.Sprintf("        Deserialize%s(%s, json[\"%s\"]);\n", f.NestedType.Name, member, jsonName))
 			buf.WriteString("    }\n")
 		}
 
@@ -613,7 +1082,7 @@ func (g *AdapterGenerator) generateDeserializeFieldNlohmann(f *types.Field) (str
 // generateDeserializeFieldJsonCpp generates JsonCpp deserialization code
 func (g *AdapterGenerator) generateDeserializeFieldJsonCpp(f *types.Field) (string, error) {
 	var buf bytes.Buffer
-	fieldName := g.getFieldName(f.Name)
+	member := g.targetRef(f)
 	jsonName := f.JSONName
 
 	switch f.Type {
@@ -622,56 +1091,61 @@ func (g *AdapterGenerator) generateDeserializeFieldJsonCpp(f *types.Field) (stri
 
 	case types.JSONBool:
 		buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isBool()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].asBool();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].asBool();\n", member, jsonName))
 		buf.WriteString("    }\n")
 
 	case types.JSONInt:
-		buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isInt64()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].asInt64();\n", fieldName, jsonName))
+		if isUnsignedScalar(f) {
+			buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isUInt64()) {\n", jsonName, jsonName))
+			buf.WriteString(fmt.Sprintf("        %s = %s;\n", member, castScalar(f, fmt.Sprintf("json[\"%s\"].asUInt64()", jsonName))))
+		} else {
+			buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isInt64()) {\n", jsonName, jsonName))
+			buf.WriteString(fmt.Sprintf("        %s = %s;\n", member, castScalar(f, fmt.Sprintf("json[\"%s\"].asInt64()", jsonName))))
+		}
 		buf.WriteString("    }\n")
 
 	case types.JSONFloat:
 		buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isDouble()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].asDouble();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = %s;\n", member, castScalar(f, fmt.Sprintf("json[\"%s\"].asDouble()", jsonName))))
 		buf.WriteString("    }\n")
 
 	case types.JSONString:
 		buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isString()) {\n", jsonName, jsonName))
-		buf.WriteString(fmt.Sprintf("        obj.%s = json[\"%s\"].asString();\n", fieldName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s = json[\"%s\"].asString();\n", member, jsonName))
 		buf.WriteString("    }\n")
 
 	case types.JSONArray:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isArray()) {\n", jsonName, jsonName))
 			buf.WriteString(fmt.Sprintf("        const Json::Value& arr = json[\"%s\"];\n", jsonName))
-			buf.WriteString(fmt.Sprintf("        obj.%s.clear();\n", fieldName))
+			buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
 			buf.WriteString("        for (Json::ArrayIndex i = 0; i < arr.size(); ++i) {\n")
 			buf.WriteString(fmt.Sprintf("            %s item;\n", f.NestedType.Name))
 			buf.WriteString(fmt.Sprintf("            Deserialize%s(item, arr[i]);\n", f.NestedType.Name))
-			buf.WriteString(fmt.Sprintf("            obj.%s.push_back(item);\n", fieldName))
+			buf.WriteString(fmt.Sprintf("            %s.push_back(item);\n", member))
 			buf.WriteString("        }\n")
 			buf.WriteString("    }\n")
 		} else {
 			buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isArray()) {\n", jsonName, jsonName))
 			buf.WriteString(fmt.Sprintf("        const Json::Value& arr = json[\"%s\"];\n", jsonName))
-			buf.WriteString(fmt.Sprintf("        obj.%s.clear();\n", fieldName))
+			buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
 			buf.WriteString("        for (Json::ArrayIndex i = 0; i < arr.size(); ++i) {\n")
 			switch f.ElemType {
 			case types.JSONString:
 				buf.WriteString("            if (arr[i].isString()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(arr[i].asString());\n", fieldName))
+				buf.WriteString(fmt.Sprintf("                %s.push_back(arr[i].asString());\n", member))
 				buf.WriteString("            }\n")
 			case types.JSONInt:
 				buf.WriteString("            if (arr[i].isInt64()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(arr[i].asInt64());\n", fieldName))
+				buf.WriteString(fmt.Sprintf("                %s.push_back(arr[i].asInt64());\n", member))
 				buf.WriteString("            }\n")
 			case types.JSONFloat:
 				buf.WriteString("            if (arr[i].isDouble()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(arr[i].asDouble());\n", fieldName))
+				buf.WriteString(fmt.Sprintf("                %s.push_back(arr[i].asDouble());\n", member))
 				buf.WriteString("            }\n")
 			case types.JSONBool:
 				buf.WriteString("            if (arr[i].isBool()) {\n")
-				buf.WriteString(fmt.Sprintf("                obj.%s.push_back(arr[i].asBool());\n", fieldName))
+				buf.WriteString(fmt.Sprintf("                %s.push_back(arr[i].asBool());\n", member))
 				buf.WriteString("            }\n")
 			}
 			buf.WriteString("        }\n")
@@ -681,7 +1155,7 @@ func (g *AdapterGenerator) generateDeserializeFieldJsonCpp(f *types.Field) (stri
 	case types.JSONObject:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isObject()) {\n", jsonName, jsonName))
-			buf.WriteString(fmt.Sprintf("        Deserialize%s(obj.%s, json[\"%s\"]);\n", f.NestedType.Name, fieldName, jsonName))
+			buf.WriteString(fmt.Sprintf("        Deserialize%s(%s, json[\"%s\"]);\n", f.NestedType.Name, member, jsonName))
 			buf.WriteString("    }\n")
 		}
 
@@ -706,6 +1180,13 @@ func (g *AdapterGenerator) generateSerializeFunction(s *types.Struct) (string, e
 		buf.WriteString(fmt.Sprintf("void Serialize%s(const %s& obj, Json::Value& json) {\n", s.Name, s.Name))
 	}
 
+	if s.Union != nil {
+		buf.WriteString(g.generateUnionSerializeBody(s))
+	}
+	if s.Base != nil {
+		buf.WriteString("    " + g.serializeCall(s.Base.Name, "obj") + "\n")
+	}
+
 	for _, f := range s.Fields {
 		code, err := g.generateSerializeField(f)
 		if err != nil {
@@ -721,6 +1202,30 @@ func (g *AdapterGenerator) generateSerializeFunction(s *types.Struct) (string, e
 
 // generateSerializeField generates parser-specific serialization code for a single field
 func (g *AdapterGenerator) generateSerializeField(f *types.Field) (string, error) {
+	code, err := g.generateSerializeValue(f)
+	switch {
+	case err != nil:
+		return "", err
+	case g.isNullableMember(f):
+		return g.wrapNullableSerialize(f, code), nil
+	case g.isOptionalMember(f):
+		return g.wrapOptionalSerialize(f, code), nil
+	default:
+		return code, nil
+	}
+}
+
+// generateSerializeValue generates the code writing the value of a field
+func (g *AdapterGenerator) generateSerializeValue(f *types.Field) (string, error) {
+	if isVariant(f) {
+		return g.generateSerializeVariant(f)
+	}
+	if f.Format != types.FormatDefault {
+		return g.generateSerializeFormatted(f)
+	}
+	if hasNullableElems(f) {
+		return g.generateSerializeNullableElems(f)
+	}
 	switch g.parser {
 	case ParserRapidJSON:
 		return g.generateSerializeFieldRapidJSON(f)
@@ -736,7 +1241,7 @@ func (g *AdapterGenerator) generateSerializeField(f *types.Field) (string, error
 // generateSerializeFieldRapidJSON generates RapidJSON serialization code
 func (g *AdapterGenerator) generateSerializeFieldRapidJSON(f *types.Field) (string, error) {
 	var buf bytes.Buffer
-	fieldName := g.getFieldName(f.Name)
+	member := g.memberRef(f)
 	jsonName := f.JSONName
 
 	switch f.Type {
@@ -744,28 +1249,28 @@ func (g *AdapterGenerator) generateSerializeFieldRapidJSON(f *types.Field) (stri
 		return "", nil
 
 	case types.JSONBool:
-		buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", obj.%s, allocator);\n", jsonName, fieldName))
+		buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", %s, allocator);\n", jsonName, member))
 
 	case types.JSONInt:
-		buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", obj.%s, allocator);\n", jsonName, fieldName))
+		buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", %s, allocator);\n", jsonName, member))
 
 	case types.JSONFloat:
-		buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", obj.%s, allocator);\n", jsonName, fieldName))
+		buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", %s, allocator);\n", jsonName, member))
 
 	case types.JSONString:
-		buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", rapidjson::Value(obj.%s.c_str(), allocator), allocator);\n", jsonName, fieldName))
+		buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", rapidjson::Value(%s.c_str(), allocator), allocator);\n", jsonName, member))
 
 	case types.JSONArray:
 		buf.WriteString("    {\n")
 		buf.WriteString("        rapidjson::Value arr(rapidjson::kArrayType);\n")
 		if f.NestedType != nil {
-			buf.WriteString(fmt.Sprintf("        for (const auto& item : obj.%s) {\n", fieldName))
+			buf.WriteString(fmt.Sprintf("        for (const auto& item : %s) {\n", member))
 			buf.WriteString("            rapidjson::Value elem(rapidjson::kObjectType);\n")
 			buf.WriteString(fmt.Sprintf("            Serialize%s(item, elem, allocator);\n", f.NestedType.Name))
 			buf.WriteString("            arr.PushBack(elem, allocator);\n")
 			buf.WriteString("        }\n")
 		} else {
-			buf.WriteString(fmt.Sprintf("        for (const auto& item : obj.%s) {\n", fieldName))
+			buf.WriteString(fmt.Sprintf("        for (const auto& item : %s) {\n", member))
 			switch f.ElemType {
 			case types.JSONString:
 				buf.WriteString("            arr.PushBack(rapidjson::Value(item.c_str(), allocator), allocator);\n")
@@ -781,7 +1286,7 @@ func (g *AdapterGenerator) generateSerializeFieldRapidJSON(f *types.Field) (stri
 		if f.NestedType != nil {
 			buf.WriteString("    {\n")
 			buf.WriteString("        rapidjson::Value nested(rapidjson::kObjectType);\n")
-			buf.WriteString(fmt.Sprintf("        Serialize%s(obj.%s, nested, allocator);\n", f.NestedType.Name, fieldName))
+			buf.WriteString(fmt.Sprintf("        Serialize%s(%s, nested, allocator);\n", f.NestedType.Name, member))
 			buf.WriteString(fmt.Sprintf("        json.AddMember(\"%s\", nested, allocator);\n", jsonName))
 			buf.WriteString("    }\n")
 		}
@@ -796,7 +1301,7 @@ func (g *AdapterGenerator) generateSerializeFieldRapidJSON(f *types.Field) (stri
 // generateSerializeFieldNlohmann generates nlohmann/json serialization code
 func (g *AdapterGenerator) generateSerializeFieldNlohmann(f *types.Field) (string, error) {
 	var buf bytes.Buffer
-	fieldName := g.getFieldName(f.Name)
+	member := g.memberRef(f)
 	jsonName := f.JSONName
 
 	switch f.Type {
@@ -804,24 +1309,24 @@ func (g *AdapterGenerator) generateSerializeFieldNlohmann(f *types.Field) (strin
 		return "", nil
 
 	case types.JSONBool, types.JSONInt, types.JSONFloat, types.JSONString:
-		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = obj.%s;\n", jsonName, fieldName))
+		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = %s;\n", jsonName, member))
 
 	case types.JSONArray:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    json[\"%s\"] = nlohmann::json::array();\n", jsonName))
-			buf.WriteString(fmt.Sprintf("    for (const auto& item : obj.%s) {\n", fieldName))
+			buf.WriteString(fmt.Sprintf("    for (const auto& item : %s) {\n", member))
 			buf.WriteString("        nlohmann::json elem;\n")
 			buf.WriteString(fmt.Sprintf("        Serialize%s(item, elem);\n", f.NestedType.Name))
 			buf.WriteString(fmt.Sprintf("        json[\"%s\"].push_back(elem);\n", jsonName))
 			buf.WriteString("    }\n")
 		} else {
-			buf.WriteString(fmt.Sprintf("    json[\"%s\"] = obj.%s;\n", jsonName, fieldName))
+			buf.WriteString(fmt.Sprintf("    json[\"%s\"] = %s;\n", jsonName, member))
 		}
 
 	case types.JSONObject:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    json[\"%s\"] = nlohmann::json::object();\n", jsonName))
-			buf.WriteString(fmt.Sprintf("    Serialize%s(obj.%s, json[\"%s\"]);\n", f.NestedType.Name, fieldName, jsonName))
+			buf.WriteString(fmt.Sprintf("    Serialize%s(%s, json[\"%s\"]);\n", f.NestedType.Name, member, jsonName))
 		}
 
 	default:
@@ -834,7 +1339,7 @@ func (g *AdapterGenerator) generateSerializeFieldNlohmann(f *types.Field) (strin
 // generateSerializeFieldJsonCpp generates JsonCpp serialization code
 func (g *AdapterGenerator) generateSerializeFieldJsonCpp(f *types.Field) (string, error) {
 	var buf bytes.Buffer
-	fieldName := g.getFieldName(f.Name)
+	member := g.memberRef(f)
 	jsonName := f.JSONName
 
 	switch f.Type {
@@ -842,18 +1347,18 @@ func (g *AdapterGenerator) generateSerializeFieldJsonCpp(f *types.Field) (string
 		return "", nil
 
 	case types.JSONBool, types.JSONInt, types.JSONFloat, types.JSONString:
-		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = obj.%s;\n", jsonName, fieldName))
+		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = %s;\n", jsonName, member))
 
 	case types.JSONArray:
 		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = Json::Value(Json::arrayValue);\n", jsonName))
 		if f.NestedType != nil {
-			buf.WriteString(fmt.Sprintf("    for (const auto& item : obj.%s) {\n", fieldName))
+			buf.WriteString(fmt.Sprintf("    for (const auto& item : %s) {\n", member))
 			buf.WriteString("        Json::Value elem(Json::objectValue);\n")
 			buf.WriteString(fmt.Sprintf("        Serialize%s(item, elem);\n", f.NestedType.Name))
 			buf.WriteString(fmt.Sprintf("        json[\"%s\"].append(elem);\n", jsonName))
 			buf.WriteString("    }\n")
 		} else {
-			buf.WriteString(fmt.Sprintf("    for (const auto& item : obj.%s) {\n", fieldName))
+			buf.WriteString(fmt.Sprintf("    for (const auto& item : %s) {\n", member))
 			buf.WriteString(fmt.Sprintf("        json[\"%s\"].append(item);\n", jsonName))
 			buf.WriteString("    }\n")
 		}
@@ -861,7 +1366,7 @@ func (g *AdapterGenerator) generateSerializeFieldJsonCpp(f *types.Field) (string
 	case types.JSONObject:
 		if f.NestedType != nil {
 			buf.WriteString(fmt.Sprintf("    json[\"%s\"] = Json::Value(Json::objectValue);\n", jsonName))
-			buf.WriteString(fmt.Sprintf("    Serialize%s(obj.%s, json[\"%s\"]);\n", f.NestedType.Name, fieldName, jsonName))
+			buf.WriteString(fmt.Sprintf("    Serialize%s(%s, json[\"%s\"]);\n", f.NestedType.Name, member, jsonName))
 		}
 
 	default:
@@ -0,0 +1,273 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"json2cpp/internal/types"
+)
+
+// generateBuilders generates builders.h: a <Name>Builder per struct with a
+// chained setter per member, add_x() for array members, and build(), which
+// throws std::logic_error when a required member was not set. Tagged union
+// wrappers get no builder.
+func (g *AdapterGenerator) generateBuilders(info *types.TypeInfo) (string, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString("// Auto-generated by json2cpp\n")
+	buf.WriteString("// Fluent builders for the types in types.h (parser-independent)\n\n")
+	buf.WriteString("#ifndef JSON2CPP_BUILDERS_H\n")
+	buf.WriteString("#define JSON2CPP_BUILDERS_H\n\n")
+	buf.WriteString("#include \"types.h\"\n")
+	buf.WriteString("#include <stdexcept>\n")
+	buf.WriteString("#include <string>\n\n")
+
+	if g.namespace != "" {
+		buf.WriteString(fmt.Sprintf("namespace %s {\n", g.namespace))
+	}
+
+	// Same order as types.h, so nested builders come first
+	for i := len(info.Structs) - 1; i >= 0; i-- {
+		s := info.Structs[i]
+		if s.Union != nil {
+			continue
+		}
+		code, err := g.generateBuilder(s)
+		if err != nil {
+			return "", fmt.Errorf("builder for %s: %w", s.Name, err)
+		}
+		buf.WriteString("\n")
+		buf.WriteString(code)
+	}
+
+	if g.namespace != "" {
+		buf.WriteString("\n} // namespace ")
+		buf.WriteString(g.namespace)
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("\n#endif // JSON2CPP_BUILDERS_H\n")
+
+	return buf.String(), nil
+}
+
+// flattenedFields returns the fields of a struct preceded by those of its
+// base structs, as if declared in the struct
+func flattenedFields(s *types.Struct) []*types.Field {
+	var fields []*types.Field
+	for b := s.Base; b != nil; b = b.Base {
+		fields = append(append([]*types.Field{}, b.Fields...), fields...)
+	}
+	return append(fields, s.Fields...)
+}
+
+// isRequiredField reports whether build() insists on a member being set: it
+// was in every sample, is not wrapped, and has no hint default
+func (g *AdapterGenerator) isRequiredField(f *types.Field) bool {
+	return !f.IsOptional && !g.isOptionalMember(f) && !g.isNullableMember(f) && f.Default == ""
+}
+
+// isArithmeticType reports whether a C++ type is passed by value
+func isArithmeticType(cppType string) bool {
+	switch cppType {
+	case "bool", "double", "float":
+		return true
+	}
+	return strings.HasPrefix(cppType, "int") || strings.HasPrefix(cppType, "uint")
+}
+
+// parameter declares a parameter of the given type
+func parameter(cppType, name string) string {
+	if isArithmeticType(cppType) {
+		return cppType + " " + name
+	}
+	return fmt.Sprintf("const %s& %s", cppType, name)
+}
+
+// builderStruct returns the struct with a builder nested in a member or its
+// elements, or nil
+func builderStruct(f *types.Field) *types.Struct {
+	if f.NestedType == nil || f.NestedType.Union != nil || isVariant(f) {
+		return nil
+	}
+	return f.NestedType
+}
+
+// generateBuilder returns the builder class of a struct
+func (g *AdapterGenerator) generateBuilder(s *types.Struct) (string, error) {
+	g.usedNames = make(map[string]int)
+	builder := s.Name + "Builder"
+	fields := flattenedFields(s)
+
+	var required []*types.Field
+	for _, f := range fields {
+		if g.isRequiredField(f) {
+			required = append(required, f)
+		}
+	}
+
+	var pending []*types.Field
+	for _, f := range fields {
+		if g.collectsElements(f) {
+			pending = append(pending, f)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("class %s {\n", builder))
+	buf.WriteString("public:\n")
+	if len(required) > 0 {
+		var inits []string
+		for _, f := range required {
+			inits = append(inits, fmt.Sprintf("has_%s_(false)", g.getFieldName(f.Name)))
+		}
+		buf.WriteString(fmt.Sprintf("    %s() : %s {}\n\n", builder, strings.Join(inits, ", ")))
+	}
+
+	for _, f := range fields {
+		code, err := g.generateBuilderSetters(builder, f)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(code)
+		buf.WriteString("\n")
+	}
+
+	if len(required) > 0 {
+		buf.WriteString("    // Throws std::logic_error when a required member was not set\n")
+	}
+	buf.WriteString(fmt.Sprintf("    %s build() const {\n", s.Name))
+	if len(required) > 0 {
+		buf.WriteString("        std::string missing;\n")
+		for _, f := range required {
+			name := g.getFieldName(f.Name)
+			buf.WriteString(fmt.Sprintf("        if (!has_%s_) {\n", name))
+			buf.WriteString(fmt.Sprintf("            missing += \", %s\";\n", name))
+			buf.WriteString("        }\n")
+		}
+		buf.WriteString("        if (!missing.empty()) {\n")
+		buf.WriteString(fmt.Sprintf("            throw std::logic_error(\"%s: missing required members \" + missing.substr(2));\n", s.Name))
+		buf.WriteString("        }\n")
+	}
+	if len(pending) == 0 {
+		buf.WriteString("        return obj_;\n")
+	} else {
+		buf.WriteString(fmt.Sprintf("        %s result = obj_;\n", s.Name))
+		for _, f := range pending {
+			buf.WriteString(fmt.Sprintf("        result.%s(%s);\n", g.setterName(f), g.elementsName(f)))
+		}
+		buf.WriteString("        return result;\n")
+	}
+	buf.WriteString("    }\n\n")
+
+	buf.WriteString("private:\n")
+	buf.WriteString(fmt.Sprintf("    %s obj_;\n", s.Name))
+	for _, f := range pending {
+		memberType, err := g.getCppType(f)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(fmt.Sprintf("    %s %s;\n", memberType, g.elementsName(f)))
+	}
+	for _, f := range required {
+		buf.WriteString(fmt.Sprintf("    bool has_%s_;\n", g.getFieldName(f.Name)))
+	}
+	buf.WriteString("};\n")
+	return buf.String(), nil
+}
+
+// generateBuilderSetters returns the chained setters of a member: one taking
+// the value, one taking a nested builder, and for arrays add_x() appending an
+// element, with a nested builder overload too. Optional and Nullable members
+// are set from their value type.
+func (g *AdapterGenerator) generateBuilderSetters(builder string, f *types.Field) (string, error) {
+	valueType, err := g.valueCppType(f)
+	if err != nil {
+		return "", err
+	}
+	name := g.getFieldName(f.Name)
+	nested := builderStruct(f)
+	wrapped := g.isOptionalMember(f) || g.isNullableMember(f)
+
+	var buf bytes.Buffer
+	method := func(method, param string, body []string) {
+		buf.WriteString(fmt.Sprintf("    %s& %s(%s) {\n", builder, method, param))
+		for _, line := range body {
+			buf.WriteString("        " + line + "\n")
+		}
+		buf.WriteString("        return *this;\n")
+		buf.WriteString("    }\n")
+	}
+	set := g.builderAssign(f, "value")
+	if g.isRequiredField(f) {
+		set = append(set, fmt.Sprintf("has_%s_ = true;", name))
+	}
+
+	method(name, parameter(valueType, "value"), set)
+	if f.Type == types.JSONObject && nested != nil {
+		buf.WriteString(fmt.Sprintf("    %s& %s(const %sBuilder& builder) {\n", builder, name, nested.Name))
+		buf.WriteString(fmt.Sprintf("        return %s(builder.build());\n", name))
+		buf.WriteString("    }\n")
+	}
+	if f.Type != types.JSONArray || !strings.HasPrefix(valueType, "std::vector<") {
+		return buf.String(), nil
+	}
+
+	elemType := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(valueType, "std::vector<"), ">"))
+	add := g.builderAppend(f, wrapped)
+	if g.isRequiredField(f) {
+		add = append(add, fmt.Sprintf("has_%s_ = true;", name))
+	}
+	method("add_"+name, parameter(elemType, "value"), add)
+	if nested != nil {
+		buf.WriteString(fmt.Sprintf("    %s& add_%s(const %sBuilder& builder) {\n", builder, name, nested.Name))
+		buf.WriteString(fmt.Sprintf("        return add_%s(builder.build());\n", name))
+		buf.WriteString("    }\n")
+	}
+	return buf.String(), nil
+}
+
+// collectsElements reports whether the builder of a class keeps the elements
+// of an array member itself: the getter of a class is const, so appending to
+// the member would copy it each time. build() passes them to the setter.
+func (g *AdapterGenerator) collectsElements(f *types.Field) bool {
+	return g.encapsulate && f.Type == types.JSONArray
+}
+
+// elementsName names the builder member collecting the elements of an array
+// member of a class
+func (g *AdapterGenerator) elementsName(f *types.Field) string {
+	return g.getFieldName(f.Name) + "_items_"
+}
+
+// builderAssign returns the statements setting a member of obj_ to value,
+// through its setter in a class, or the elements the builder collects
+func (g *AdapterGenerator) builderAssign(f *types.Field, value string) []string {
+	switch {
+	case g.collectsElements(f):
+		return []string{fmt.Sprintf("%s = %s;", g.elementsName(f), value)}
+	case g.encapsulate:
+		return []string{fmt.Sprintf("obj_.%s(%s);", g.setterName(f), value)}
+	default:
+		return []string{fmt.Sprintf("obj_.%s = %s;", g.memberName(f), value)}
+	}
+}
+
+// builderAppend returns the statements appending value to an array member of
+// obj_, or to the elements the builder collects for a class
+func (g *AdapterGenerator) builderAppend(f *types.Field, wrapped bool) []string {
+	member := "obj_." + g.memberName(f)
+	if g.collectsElements(f) {
+		member = g.elementsName(f)
+	}
+	if !wrapped {
+		return []string{member + ".push_back(value);"}
+	}
+	return []string{
+		fmt.Sprintf("if (!%s.has_value()) {", member),
+		fmt.Sprintf("    %s.emplace();", member),
+		"}",
+		member + "->push_back(value);",
+	}
+}
@@ -0,0 +1,182 @@
+package codegen
+
+import (
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestFlattenedFields(t *testing.T) {
+	grand := &types.Struct{Name: "Entity", Fields: []*types.Field{{Name: "id"}}}
+	parent := &types.Struct{Name: "Audit", Base: grand, Fields: []*types.Field{{Name: "created"}, {Name: "updated"}}}
+	s := &types.Struct{Name: "User", Base: parent, Fields: []*types.Field{{Name: "name"}}}
+
+	var got []string
+	for _, f := range flattenedFields(s) {
+		got = append(got, f.Name)
+	}
+	want := []string{"id", "created", "updated", "name"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if len(parent.Fields) != 2 {
+		t.Errorf("base fields modified: %d", len(parent.Fields))
+	}
+}
+
+func TestIsRequiredField(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		field types.Field
+		want  bool
+	}{
+		{"in every sample", Config{}, types.Field{Type: types.JSONInt}, true},
+		{"missing from a sample", Config{}, types.Field{Type: types.JSONInt, IsOptional: true}, false},
+		{"hint default", Config{}, types.Field{Type: types.JSONInt, Default: "3"}, false},
+		{"Nullable", Config{Nullable: true}, types.Field{Type: types.JSONInt, Nullable: true}, false},
+		// Without --nullable the member is a plain value and must be set
+		{"null seen without --nullable", Config{}, types.Field{Type: types.JSONInt, Nullable: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := tt.field
+			if got := NewAdapterGenerator(tt.cfg, "").isRequiredField(&field); got != tt.want {
+				t.Errorf("isRequiredField = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParameter(t *testing.T) {
+	tests := []struct {
+		cppType string
+		want    string
+	}{
+		{"bool", "bool value"},
+		{"double", "double value"},
+		{"uint8_t", "uint8_t value"},
+		{"int64_t", "int64_t value"},
+		{"std::string", "const std::string& value"},
+		{"Decimal", "const Decimal& value"},
+		{"std::vector<int64_t>", "const std::vector<int64_t>& value"},
+	}
+	for _, tt := range tests {
+		if got := parameter(tt.cppType, "value"); got != tt.want {
+			t.Errorf("parameter(%q) = %q, want %q", tt.cppType, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateBuilder(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		s       *types.Struct
+		want    []string
+		notWant []string
+	}{
+		{"nothing required", Config{},
+			&types.Struct{Name: "P", Fields: []*types.Field{
+				{Name: "x", Type: types.JSONInt, IsOptional: true},
+			}},
+			[]string{"    PBuilder& x(int64_t value) {\n        obj_.x = value;\n        return *this;\n    }\n",
+				"    P build() const {\n        return obj_;\n    }\n\nprivate:\n    P obj_;\n};\n"},
+			[]string{"PBuilder()", "missing", "has_x_"}},
+		{"required members listed in the exception", Config{},
+			&types.Struct{Name: "P", Fields: []*types.Field{
+				{Name: "x", Type: types.JSONInt}, {Name: "label", Type: types.JSONString},
+			}},
+			[]string{"    PBuilder() : has_x_(false), has_label_(false) {}\n",
+				"        obj_.label = value;\n        has_label_ = true;\n",
+				"        if (!has_x_) {\n            missing += \", x\";\n        }\n",
+				"throw std::logic_error(\"P: missing required members \" + missing.substr(2));",
+				"    bool has_x_;\n    bool has_label_;\n};\n"},
+			nil},
+		{"base members are set on the derived builder", Config{},
+			&types.Struct{Name: "User", Base: &types.Struct{Name: "Audit", Fields: []*types.Field{
+				{Name: "id", Type: types.JSONInt, IsOptional: true},
+			}}},
+			[]string{"    UserBuilder& id(int64_t value) {\n        obj_.id = value;\n"}, nil},
+		{"Optional set from its value type", Config{OptionalNull: true},
+			&types.Struct{Name: "P", Fields: []*types.Field{
+				{Name: "tags", Type: types.JSONArray, ElemType: types.JSONString, IsOptional: true},
+			}},
+			[]string{"    PBuilder& tags(const std::vector<std::string>& value) {\n",
+				"    PBuilder& add_tags(const std::string& value) {\n" +
+					"        if (!obj_.tags.has_value()) {\n" +
+					"            obj_.tags.emplace();\n" +
+					"        }\n" +
+					"        obj_.tags->push_back(value);\n"},
+			nil},
+		// The getter of a class is const, so its builder collects the
+		// elements itself and build() hands them to the setter
+		{"class array member", Config{Encapsulate: true},
+			&types.Struct{Name: "P", Fields: []*types.Field{
+				{Name: "ids", Type: types.JSONArray, ElemType: types.JSONInt},
+			}},
+			[]string{"    PBuilder& ids(const std::vector<int64_t>& value) {\n" +
+				"        ids_items_ = value;\n" +
+				"        has_ids_ = true;\n",
+				"    PBuilder& add_ids(int64_t value) {\n" +
+					"        ids_items_.push_back(value);\n" +
+					"        has_ids_ = true;\n",
+				"        P result = obj_;\n" +
+					"        result.set_ids(ids_items_);\n" +
+					"        return result;\n",
+				"    P obj_;\n    std::vector<int64_t> ids_items_;\n    bool has_ids_;\n"},
+			[]string{"obj_.ids()", "return obj_;"}},
+		{"Optional class array member", Config{Encapsulate: true, OptionalNull: true},
+			&types.Struct{Name: "P", Fields: []*types.Field{
+				{Name: "ids", Type: types.JSONArray, ElemType: types.JSONInt, IsOptional: true},
+			}},
+			[]string{"        if (!ids_items_.has_value()) {\n" +
+				"            ids_items_.emplace();\n" +
+				"        }\n" +
+				"        ids_items_->push_back(value);\n",
+				"    Optional<std::vector<int64_t>> ids_items_;\n"},
+			nil},
+		{"class scalar member set through its setter", Config{Encapsulate: true},
+			&types.Struct{Name: "P", Fields: []*types.Field{{Name: "x", Type: types.JSONInt}}},
+			[]string{"        obj_.set_x(value);\n", "        return obj_;\n"},
+			[]string{"x_items_"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := NewAdapterGenerator(tt.cfg, "").generateBuilder(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, out, tt.want, tt.notWant)
+		})
+	}
+}
+
+func TestNestedBuilderOverloads(t *testing.T) {
+	item := &types.Struct{Name: "Item", Fields: []*types.Field{{Name: "sku", Type: types.JSONString}}}
+	shape := &types.Struct{Name: "Shape", Union: &types.Union{Tag: "type"}}
+	info := &types.TypeInfo{Structs: []*types.Struct{
+		{Name: "Order", Fields: []*types.Field{
+			{Name: "first", Type: types.JSONObject, NestedType: item},
+			{Name: "items", Type: types.JSONArray, ElemType: types.JSONObject, NestedType: item},
+			{Name: "shape", Type: types.JSONObject, NestedType: shape},
+		}},
+		item, shape,
+	}}
+
+	out, err := NewAdapterGenerator(Config{Namespace: "api"}, "").generateBuilders(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, out, []string{
+		"namespace api {\n\nclass ItemBuilder {\n",
+		"    OrderBuilder& first(const ItemBuilder& builder) {\n        return first(builder.build());\n    }\n",
+		"    OrderBuilder& add_items(const ItemBuilder& builder) {\n        return add_items(builder.build());\n    }\n",
+		"} // namespace api\n\n#endif // JSON2CPP_BUILDERS_H\n",
+	}, []string{"ShapeBuilder"})
+}
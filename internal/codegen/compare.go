@@ -0,0 +1,208 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"json2cpp/internal/types"
+)
+
+// decimalEquality is emitted after Decimal with --equality. Decimals compare
+// by value, so 1.5 == 1.50.
+const decimalEquality = `// Compares decimals by value: negative, zero or positive as a is less than,
+// equal to or greater than b. 1.5 and 1.50 are equal.
+inline int CompareDecimals(const Decimal& a, const Decimal& b) {
+    if (a.scale > b.scale) {
+        return -CompareDecimals(b, a);
+    }
+    // Compare a * 10^d with b = q * 10^d + r; |r| < 10^d decides a tie on q.
+    int d = b.scale - a.scale;
+    int64_t q = 0;
+    int64_t r = b.mantissa;
+    if (d <= 18) {
+        int64_t p = 1;
+        for (int i = 0; i < d; ++i) {
+            p *= 10;
+        }
+        q = b.mantissa / p;
+        r = b.mantissa % p;
+    }
+    if (a.mantissa != q) {
+        return a.mantissa < q ? -1 : 1;
+    }
+    return r > 0 ? -1 : (r < 0 ? 1 : 0);
+}
+
+inline bool operator==(const Decimal& a, const Decimal& b) {
+    return CompareDecimals(a, b) == 0;
+}
+
+inline bool operator!=(const Decimal& a, const Decimal& b) {
+    return CompareDecimals(a, b) != 0;
+}
+`
+
+// decimalOrdering is emitted after decimalEquality with --ordering.
+const decimalOrdering = `inline bool operator<(const Decimal& a, const Decimal& b) {
+    return CompareDecimals(a, b) < 0;
+}
+`
+
+// optionalEquality is emitted after the bundled Optional with --equality;
+// std::optional has its own.
+const optionalEquality = `template <typename T>
+bool operator==(const Optional<T>& a, const Optional<T>& b) {
+    return a.has_value() == b.has_value() && (!a.has_value() || *a == *b);
+}
+
+template <typename T>
+bool operator!=(const Optional<T>& a, const Optional<T>& b) {
+    return !(a == b);
+}
+`
+
+// optionalOrdering is emitted after optionalEquality with --ordering.
+const optionalOrdering = `// An empty Optional orders before any value, as with std::optional.
+template <typename T>
+bool operator<(const Optional<T>& a, const Optional<T>& b) {
+    return b.has_value() && (!a.has_value() || *a < *b);
+}
+`
+
+// nullableEquality is emitted after Nullable with --equality.
+const nullableEquality = `template <typename T>
+bool operator==(const Nullable<T>& a, const Nullable<T>& b) {
+    return a.state() == b.state() && (!a.has_value() || *a == *b);
+}
+
+template <typename T>
+bool operator!=(const Nullable<T>& a, const Nullable<T>& b) {
+    return !(a == b);
+}
+`
+
+// nullableOrdering is emitted after nullableEquality with --ordering.
+const nullableOrdering = `// Undefined orders before null, and null before any value.
+template <typename T>
+bool operator<(const Nullable<T>& a, const Nullable<T>& b) {
+    if (a.state() != b.state()) {
+        return a.state() < b.state();
+    }
+    return a.has_value() && *a < *b;
+}
+`
+
+// writeComparisonHelpers writes the operators of a bundled type after its
+// definition: equality, then ordering when requested.
+func (g *AdapterGenerator) writeComparisonHelpers(buf *bytes.Buffer, equality, ordering string) {
+	if !g.equality {
+		return
+	}
+	buf.WriteString(equality)
+	buf.WriteString("\n")
+	if g.ordering {
+		buf.WriteString(ordering)
+		buf.WriteString("\n")
+	}
+}
+
+// defaultedComparisons returns the defaulted comparison members of a C++20
+// struct. The partial ordering lets members without operator<=>, such as
+// Decimal or Nullable, be compared through their == and <.
+func (g *AdapterGenerator) defaultedComparisons(name string) string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("    bool operator==(const %s&) const = default;\n", name))
+	if g.ordering {
+		buf.WriteString(fmt.Sprintf("    std::partial_ordering operator<=>(const %s&) const = default;\n", name))
+	}
+	return buf.String()
+}
+
+// comparedParts returns accessors for the parts compared in a struct, in
+// declaration order: the base subobject first, then each member. An accessor
+// maps the expression of an object to that of its part.
+func (g *AdapterGenerator) comparedParts(s *types.Struct) []func(obj string) string {
+	var parts []func(obj string) string
+	if s.Base != nil {
+		base := s.Base.Name
+		parts = append(parts, func(obj string) string {
+			return fmt.Sprintf("static_cast<const %s&>(%s)", base, obj)
+		})
+	}
+	var members []string
+	switch {
+	case s.Union != nil && g.useVariant():
+		members = append(members, "value")
+	case s.Union != nil:
+		tagMember, variants := g.unionMembers(s)
+		members = append(append(members, tagMember), variants...)
+	default:
+		for _, f := range s.Fields {
+			field := f
+			parts = append(parts, func(obj string) string {
+				return g.memberAccess(field, obj)
+			})
+		}
+	}
+	for _, m := range members {
+		member := m
+		parts = append(parts, func(obj string) string {
+			return obj + "." + member
+		})
+	}
+	return parts
+}
+
+// generateComparisonOperators returns hand-written operator==, operator!= and,
+// with --ordering, a lexicographic operator< for a struct, for standards
+// before C++20.
+func (g *AdapterGenerator) generateComparisonOperators(s *types.Struct) string {
+	parts := g.comparedParts(s)
+	compare := func(part func(string) string, a, op, b string) string {
+		return fmt.Sprintf("%s %s %s", part(a), op, part(b))
+	}
+
+	var buf bytes.Buffer
+	if len(parts) == 0 {
+		buf.WriteString(fmt.Sprintf("inline bool operator==(const %s&, const %s&) {\n", s.Name, s.Name))
+		buf.WriteString("    return true;\n")
+	} else {
+		buf.WriteString(fmt.Sprintf("inline bool operator==(const %s& a, const %s& b) {\n", s.Name, s.Name))
+		for i, part := range parts {
+			if i == 0 {
+				buf.WriteString("    return " + compare(part, "a", "==", "b"))
+			} else {
+				buf.WriteString("\n        && " + compare(part, "a", "==", "b"))
+			}
+		}
+		buf.WriteString(";\n")
+	}
+	buf.WriteString("}\n\n")
+
+	buf.WriteString(fmt.Sprintf("inline bool operator!=(const %s& a, const %s& b) {\n", s.Name, s.Name))
+	buf.WriteString("    return !(a == b);\n")
+	buf.WriteString("}\n")
+
+	if !g.ordering {
+		return buf.String()
+	}
+	buf.WriteString("\n")
+	if len(parts) == 0 {
+		buf.WriteString(fmt.Sprintf("inline bool operator<(const %s&, const %s&) {\n", s.Name, s.Name))
+		buf.WriteString("    return false;\n")
+		buf.WriteString("}\n")
+		return buf.String()
+	}
+	buf.WriteString(fmt.Sprintf("inline bool operator<(const %s& a, const %s& b) {\n", s.Name, s.Name))
+	last := len(parts) - 1
+	for _, part := range parts[:last] {
+		buf.WriteString(fmt.Sprintf("    if (%s) {\n", compare(part, "a", "<", "b")))
+		buf.WriteString("        return true;\n")
+		buf.WriteString("    }\n")
+		buf.WriteString(fmt.Sprintf("    if (%s) {\n", compare(part, "b", "<", "a")))
+		buf.WriteString("        return false;\n")
+		buf.WriteString("    }\n")
+	}
+	buf.WriteString(fmt.Sprintf("    return %s;\n", compare(parts[last], "a", "<", "b")))
+	buf.WriteString("}\n")
+	return buf.String()
+}
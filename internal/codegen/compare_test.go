@@ -0,0 +1,183 @@
+package codegen
+
+import (
+	"bytes"
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestGenerateComparisonOperators(t *testing.T) {
+	field := func(name string) *types.Field {
+		return &types.Field{Name: name, JSONName: name, Type: types.JSONInt}
+	}
+	base := &types.Struct{Name: "Audit", Fields: []*types.Field{field("id")}}
+	shape := &types.Struct{Name: "Shape", Union: &types.Union{Tag: "type", Variants: []*types.Variant{
+		{Value: "circle"}, {Value: "square"},
+	}}}
+
+	tests := []struct {
+		name   string
+		s      *types.Struct
+		cppStd int
+		want   string
+	}{
+		{
+			name: "one member",
+			s:    &types.Struct{Name: "P", Fields: []*types.Field{field("x")}},
+			want: "inline bool operator==(const P& a, const P& b) {\n" +
+				"    return a.x == b.x;\n}\n\n" +
+				"inline bool operator!=(const P& a, const P& b) {\n" +
+				"    return !(a == b);\n}\n\n" +
+				"inline bool operator<(const P& a, const P& b) {\n" +
+				"    return a.x < b.x;\n}\n",
+		},
+		{
+			name: "lexicographic",
+			s:    &types.Struct{Name: "P", Fields: []*types.Field{field("x"), field("y")}},
+			want: "inline bool operator==(const P& a, const P& b) {\n" +
+				"    return a.x == b.x\n        && a.y == b.y;\n}\n\n" +
+				"inline bool operator!=(const P& a, const P& b) {\n" +
+				"    return !(a == b);\n}\n\n" +
+				"inline bool operator<(const P& a, const P& b) {\n" +
+				"    if (a.x < b.x) {\n        return true;\n    }\n" +
+				"    if (b.x < a.x) {\n        return false;\n    }\n" +
+				"    return a.y < b.y;\n}\n",
+		},
+		{
+			name: "no members",
+			s:    &types.Struct{Name: "E"},
+			want: "inline bool operator==(const E&, const E&) {\n    return true;\n}\n\n" +
+				"inline bool operator!=(const E& a, const E& b) {\n    return !(a == b);\n}\n\n" +
+				"inline bool operator<(const E&, const E&) {\n    return false;\n}\n",
+		},
+		{
+			name: "base first",
+			s:    &types.Struct{Name: "User", Base: base, Fields: []*types.Field{field("age")}},
+			want: "inline bool operator==(const User& a, const User& b) {\n" +
+				"    return static_cast<const Audit&>(a) == static_cast<const Audit&>(b)\n" +
+				"        && a.age == b.age;\n}\n\n" +
+				"inline bool operator!=(const User& a, const User& b) {\n" +
+				"    return !(a == b);\n}\n\n" +
+				"inline bool operator<(const User& a, const User& b) {\n" +
+				"    if (static_cast<const Audit&>(a) < static_cast<const Audit&>(b)) {\n        return true;\n    }\n" +
+				"    if (static_cast<const Audit&>(b) < static_cast<const Audit&>(a)) {\n        return false;\n    }\n" +
+				"    return a.age < b.age;\n}\n",
+		},
+		{
+			name:   "tagged union: tag, then every variant",
+			s:      shape,
+			cppStd: 11,
+			want: "inline bool operator==(const Shape& a, const Shape& b) {\n" +
+				"    return a.type == b.type\n        && a.circle == b.circle\n        && a.square == b.square;\n}\n\n" +
+				"inline bool operator!=(const Shape& a, const Shape& b) {\n" +
+				"    return !(a == b);\n}\n\n" +
+				"inline bool operator<(const Shape& a, const Shape& b) {\n" +
+				"    if (a.type < b.type) {\n        return true;\n    }\n" +
+				"    if (b.type < a.type) {\n        return false;\n    }\n" +
+				"    if (a.circle < b.circle) {\n        return true;\n    }\n" +
+				"    if (b.circle < a.circle) {\n        return false;\n    }\n" +
+				"    return a.square < b.square;\n}\n",
+		},
+		{
+			name:   "std::variant union",
+			s:      shape,
+			cppStd: 17,
+			want: "inline bool operator==(const Shape& a, const Shape& b) {\n" +
+				"    return a.value == b.value;\n}\n\n" +
+				"inline bool operator!=(const Shape& a, const Shape& b) {\n" +
+				"    return !(a == b);\n}\n\n" +
+				"inline bool operator<(const Shape& a, const Shape& b) {\n" +
+				"    return a.value < b.value;\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Ordering: true, CppStandard: tt.cppStd}, "")
+			if got := g.generateComparisonOperators(tt.s); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// Without --ordering there is no operator<
+	g := NewAdapterGenerator(Config{Equality: true}, "")
+	checkOutput(t, g.generateComparisonOperators(shape), []string{"operator!="}, []string{"operator<"})
+}
+
+func TestWithComparisons(t *testing.T) {
+	s := &types.Struct{Name: "P"}
+	definition := "struct P {\n    int64_t x;\n};\n"
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+	}{
+		{"off", Config{}, definition},
+		{"C++20 equality", Config{CppStandard: 20, Equality: true},
+			"struct P {\n    int64_t x;\n\n    bool operator==(const P&) const = default;\n};\n"},
+		{"C++20 ordering", Config{CppStandard: 20, Ordering: true},
+			"struct P {\n    int64_t x;\n\n" +
+				"    bool operator==(const P&) const = default;\n" +
+				"    std::partial_ordering operator<=>(const P&) const = default;\n};\n"},
+		{"C++17 free functions", Config{CppStandard: 17, Equality: true},
+			definition + "\ninline bool operator==(const P&, const P&) {\n    return true;\n}\n\n" +
+				"inline bool operator!=(const P& a, const P& b) {\n    return !(a == b);\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewAdapterGenerator(tt.cfg, "").withComparisons(s, definition); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// The bundled Optional, Nullable and Decimal compare by value: Decimal 1.5
+// equals 1.50, and empty or null orders first
+func TestComparisonHelpersForBundledTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    []string
+		notWant []string
+	}{
+		{"equality", Config{Equality: true, OptionalNull: true, Nullable: true},
+			[]string{decimalEquality, optionalEquality, nullableEquality},
+			[]string{decimalOrdering, optionalOrdering, nullableOrdering}},
+		{"ordering", Config{Ordering: true, OptionalNull: true, Nullable: true},
+			[]string{decimalEquality + "\n" + decimalOrdering, optionalEquality + "\n" + optionalOrdering,
+				nullableEquality + "\n" + nullableOrdering},
+			nil},
+		{"std::optional has its own", Config{Ordering: true, OptionalNull: true, CppStandard: 17},
+			[]string{decimalOrdering},
+			[]string{optionalEquality, optionalOrdering}},
+		{"off", Config{OptionalNull: true, Nullable: true},
+			nil,
+			[]string{decimalEquality, optionalEquality, nullableEquality}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: []*types.Field{
+				{Name: "price", JSONName: "price", Type: types.JSONFloat, Format: types.FormatDecimal},
+				{Name: "name", JSONName: "name", Type: types.JSONString, IsOptional: true},
+				{Name: "note", JSONName: "note", Type: types.JSONString, Nullable: true},
+			}}}}
+			out, err := NewAdapterGenerator(tt.cfg, "").generateTypes(info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, out, tt.want, tt.notWant)
+		})
+	}
+
+	var buf bytes.Buffer
+	NewAdapterGenerator(Config{}, "").writeComparisonHelpers(&buf, "eq", "lt")
+	if buf.Len() != 0 {
+		t.Errorf("helpers written without --equality: %q", buf.String())
+	}
+}
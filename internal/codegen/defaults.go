@@ -0,0 +1,195 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"json2cpp/internal/types"
+)
+
+// defaultKind is the kind of value a scalar member is initialized with
+type defaultKind int
+
+const (
+	defaultNone defaultKind = iota // not a scalar: no initializer
+	defaultNull                    // only seen as null: a bool placeholder without a default
+	defaultBool
+	defaultInt
+	defaultUnsigned
+	defaultFloat
+	defaultDecimal
+	defaultString
+)
+
+// defaultKindOf returns the kind of initializer a member takes
+func (g *AdapterGenerator) defaultKindOf(f *types.Field) defaultKind {
+	if g.isOptionalMember(f) || g.isNullableMember(f) || isVariant(f) {
+		return defaultNone
+	}
+	switch f.Format {
+	case types.FormatDefault:
+	case types.FormatIntString:
+		return defaultInt
+	case types.FormatFloatString:
+		return defaultFloat
+	case types.FormatDecimal:
+		return defaultDecimal
+	default:
+		return defaultNone
+	}
+	switch f.Type {
+	case types.JSONNull:
+		return defaultNull
+	case types.JSONBool:
+		return defaultBool
+	case types.JSONInt:
+		if strings.HasPrefix(f.CppType, "uint") {
+			return defaultUnsigned
+		}
+		return defaultInt
+	case types.JSONFloat:
+		return defaultFloat
+	case types.JSONString:
+		return defaultString
+	default:
+		return defaultNone
+	}
+}
+
+// zeroLiteral returns the initializer of a member without a default value;
+// "" leaves Decimal and strings to their default constructors.
+func zeroLiteral(kind defaultKind) string {
+	switch kind {
+	case defaultNull, defaultBool:
+		return "false"
+	case defaultInt, defaultUnsigned:
+		return "0"
+	case defaultFloat:
+		return "0.0"
+	default:
+		return ""
+	}
+}
+
+// intBits returns the width of a sized integer member type, e.g. 32 for
+// "uint32_t", and 64 for int64_t and inferred members
+func intBits(cppType string) int {
+	digits := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(cppType, "u"), "int"), "_t")
+	if bits, err := strconv.Atoi(digits); err == nil {
+		return bits
+	}
+	return 64
+}
+
+// defaultLiteral converts the JSON text of a default value to a C++
+// initializer for a member of the given kind; bits is the width of integer
+// members. Numbers sent as strings are accepted for numeric members. It fails
+// when the value does not fit.
+func defaultLiteral(kind defaultKind, bits int, text string) (string, bool) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return "", false
+	}
+	number := ""
+	switch v := value.(type) {
+	case json.Number:
+		number = v.String()
+	case string:
+		if kind == defaultString {
+			return cppStringLiteral(v), true
+		}
+		number = v
+	case bool:
+		if kind == defaultBool {
+			return strconv.FormatBool(v), true
+		}
+		return "", false
+	default:
+		return "", false
+	}
+
+	switch kind {
+	case defaultInt:
+		// the most negative int64_t has no literal
+		n, err := strconv.ParseInt(number, 10, bits)
+		if err != nil || n == -1<<63 {
+			return "", false
+		}
+		return strconv.FormatInt(n, 10), true
+	case defaultUnsigned:
+		n, err := strconv.ParseUint(number, 10, bits)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatUint(n, 10), true
+	case defaultFloat:
+		x, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return "", false
+		}
+		literal := strconv.FormatFloat(x, 'g', -1, 64)
+		if !strings.ContainsAny(literal, ".e") {
+			literal += ".0"
+		}
+		return literal, true
+	case defaultDecimal:
+		return decimalLiteral(number)
+	default:
+		return "", false
+	}
+}
+
+// decimalLiteral returns the Decimal holding the exact value of number
+func decimalLiteral(number string) (string, bool) {
+	r, ok := new(big.Rat).SetString(number)
+	if !ok {
+		return "", false
+	}
+	ten := big.NewRat(10, 1)
+	for scale := 0; scale <= 18; scale++ {
+		if r.IsInt() {
+			if !r.Num().IsInt64() || r.Num().Int64() == -1<<63 {
+				return "", false
+			}
+			return fmt.Sprintf("Decimal(%d, %d)", r.Num().Int64(), scale), true
+		}
+		r.Mul(r, ten)
+	}
+	return "", false
+}
+
+// cppStringLiteral quotes s as a C++ string literal. Control characters are
+// written in octal, and "??" is broken up so that it cannot start a trigraph
+// in C++03.
+func cppStringLiteral(s string) string {
+	var buf bytes.Buffer
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case c == '\n':
+			buf.WriteString("\\n")
+		case c == '\t':
+			buf.WriteString("\\t")
+		case c == '\r':
+			buf.WriteString("\\r")
+		case c < 0x20 || c == 0x7f:
+			buf.WriteString(fmt.Sprintf("\\%03o", c))
+		case c == '?' && i > 0 && s[i-1] == '?':
+			buf.WriteString("\\?")
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
@@ -0,0 +1,200 @@
+package codegen
+
+import (
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestIntBits(t *testing.T) {
+	tests := []struct {
+		cppType string
+		want    int
+	}{
+		{"int8_t", 8},
+		{"uint16_t", 16},
+		{"int32_t", 32},
+		{"uint64_t", 64},
+		{"int64_t", 64},
+		{"", 64},
+	}
+	for _, tt := range tests {
+		if got := intBits(tt.cppType); got != tt.want {
+			t.Errorf("intBits(%q) = %d, want %d", tt.cppType, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultLiteral(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   defaultKind
+		bits   int
+		text   string
+		want   string
+		wantOK bool
+	}{
+		{"bool", defaultBool, 0, `true`, "true", true},
+		{"bool from number", defaultBool, 0, `1`, "", false},
+		{"int", defaultInt, 64, `-42`, "-42", true},
+		{"int from string", defaultInt, 64, `"42"`, "42", true},
+		{"int from fraction", defaultInt, 64, `1.5`, "", false},
+		{"int8 in range", defaultInt, 8, `-128`, "-128", true},
+		{"int8 out of range", defaultInt, 8, `1000`, "", false},
+		{"most negative int64 has no literal", defaultInt, 64, `-9223372036854775808`, "", false},
+		{"uint64 beyond int64", defaultUnsigned, 64, `18446744073709551615`, "18446744073709551615", true},
+		{"negative unsigned", defaultUnsigned, 32, `-1`, "", false},
+		{"float keeps a point", defaultFloat, 0, `3`, "3.0", true},
+		{"float exponent", defaultFloat, 0, `1e-7`, "1e-07", true},
+		{"float from bool", defaultFloat, 0, `false`, "", false},
+		{"decimal", defaultDecimal, 0, `19.99`, "Decimal(1999, 2)", true},
+		{"decimal from string", defaultDecimal, 0, `"0.10"`, "Decimal(1, 1)", true},
+		{"string", defaultString, 0, `"a\"b"`, `"a\"b"`, true},
+		{"string from number", defaultString, 0, `5`, "", false},
+		{"null", defaultInt, 64, `null`, "", false},
+		{"malformed", defaultInt, 64, `{`, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := defaultLiteral(tt.kind, tt.bits, tt.text)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("defaultLiteral(%s) = (%q, %v), want (%q, %v)", tt.text, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDecimalLiteral(t *testing.T) {
+	tests := []struct {
+		number string
+		want   string
+		wantOK bool
+	}{
+		{"0", "Decimal(0, 0)", true},
+		{"1.50", "Decimal(15, 1)", true},
+		{"-0.001", "Decimal(-1, 3)", true},
+		{"1e3", "Decimal(1000, 0)", true},
+		{"2.5e-2", "Decimal(25, 3)", true},
+		{"0.0000000000000000001", "", false}, // scale above 18
+		{"1e19", "", false},                  // beyond int64
+		{"abc", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			got, ok := decimalLiteral(tt.number)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("decimalLiteral(%s) = (%q, %v), want (%q, %v)", tt.number, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCppStringLiteral(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "abc", `"abc"`},
+		{"empty", "", `""`},
+		{"quote and backslash", `say "hi" \o/`, `"say \"hi\" \\o/"`},
+		{"whitespace escapes", "a\nb\tc\r", `"a\nb\tc\r"`},
+		{"control in octal", "\x01\x7f", `"\001\177"`},
+		{"trigraph broken up", "what??=", `"what?\?="`},
+		{"utf-8 kept", "café", `"café"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cppStringLiteral(tt.in); got != tt.want {
+				t.Errorf("cppStringLiteral(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultKindOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		field types.Field
+		cfg   Config
+		want  defaultKind
+	}{
+		{"bool", types.Field{Type: types.JSONBool}, Config{}, defaultBool},
+		{"null", types.Field{Type: types.JSONNull}, Config{}, defaultNull},
+		{"int", types.Field{Type: types.JSONInt}, Config{}, defaultInt},
+		{"uint32 hint", types.Field{Type: types.JSONInt, CppType: "uint32_t"}, Config{}, defaultUnsigned},
+		{"float", types.Field{Type: types.JSONFloat}, Config{}, defaultFloat},
+		{"string", types.Field{Type: types.JSONString}, Config{}, defaultString},
+		{"decimal", types.Field{Type: types.JSONFloat, Format: types.FormatDecimal}, Config{}, defaultDecimal},
+		{"int-string", types.Field{Type: types.JSONString, Format: types.FormatIntString}, Config{}, defaultInt},
+		{"base64", types.Field{Type: types.JSONString, Format: types.FormatBase64}, Config{}, defaultNone},
+		{"array", types.Field{Type: types.JSONArray, ElemType: types.JSONInt}, Config{}, defaultNone},
+		{"Optional", types.Field{Type: types.JSONInt, IsOptional: true}, Config{OptionalNull: true}, defaultNone},
+		{"Nullable", types.Field{Type: types.JSONInt, Nullable: true}, Config{Nullable: true}, defaultNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := tt.field
+			if got := NewAdapterGenerator(tt.cfg, "").defaultKindOf(&field); got != tt.want {
+				t.Errorf("defaultKindOf = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetDefaultInitValue(t *testing.T) {
+	tests := []struct {
+		name           string
+		field          types.Field
+		sampleDefaults bool
+		want           string
+	}{
+		{"zero", types.Field{Type: types.JSONInt, Examples: []string{"7"}}, false, "0"},
+		{"sample", types.Field{Type: types.JSONInt, Examples: []string{"7"}}, true, "7"},
+		{"hint over sample", types.Field{Type: types.JSONInt, Default: "3", Examples: []string{"7"}}, true, "3"},
+		{"string left to its constructor", types.Field{Type: types.JSONString}, false, ""},
+		{"string sample", types.Field{Type: types.JSONString, Examples: []string{`"n/a"`}}, true, `"n/a"`},
+		{"decimal sample", types.Field{Type: types.JSONFloat, Format: types.FormatDecimal, Examples: []string{"9.5"}}, true,
+			"Decimal(95, 1)"},
+		{"null takes no default", types.Field{Type: types.JSONNull, Default: "true"}, false, "false"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := tt.field
+			g := NewAdapterGenerator(Config{SampleDefaults: tt.sampleDefaults}, "")
+			if got := g.getDefaultInitValue(&field); got != tt.want {
+				t.Errorf("getDefaultInitValue = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// C++11 initializes members in class; C++03 in a default constructor
+func TestMemberInitializers(t *testing.T) {
+	fields := func() []*types.Field {
+		return []*types.Field{
+			{Name: "retries", JSONName: "retries", Type: types.JSONInt, Default: "3"},
+			{Name: "ratio", JSONName: "ratio", Type: types.JSONFloat},
+			{Name: "label", JSONName: "label", Type: types.JSONString},
+		}
+	}
+	tests := []struct {
+		name string
+		cfg  Config
+		want []string
+	}{
+		{"C++11", Config{}, []string{"    int64_t retries = 3;\n", "    double ratio = 0.0;\n", "    std::string label;\n"}},
+		{"C++03", Config{LegacyCPP: true}, []string{"    Root() : retries(3), ratio(0.0) {}\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: fields()}}}
+			out, err := NewAdapterGenerator(tt.cfg, "").generateTypes(info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, out, tt.want, nil)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"json2cpp/internal/types"
+)
+
+// Doc comments are Doxygen /// lines. JSON Pointers are set in backticks and
+// example values are truncated, so that no line ends in a backslash that
+// would continue the comment, and control characters are escaped so that
+// a key holding a newline cannot end the comment line.
+
+// structDocComment documents a struct with the JSON object it maps; derived
+// lists the structs sharing the members of an extracted base.
+func structDocComment(s *types.Struct, derived []string) string {
+	switch {
+	case len(derived) > 0:
+		return fmt.Sprintf("/// Members shared by %s.\n", strings.Join(derived, ", "))
+	case s.Path == "":
+		return "/// JSON object at the document root.\n"
+	default:
+		return fmt.Sprintf("/// JSON object at `%s`.\n", commentText(s.Path))
+	}
+}
+
+// commentText escapes the control characters of text as strconv.Quote does,
+// leaving everything else as it is
+func commentText(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if unicode.IsControl(r) {
+			quoted := strconv.QuoteRune(r)
+			b.WriteString(quoted[1 : len(quoted)-1])
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// derivedStructs returns the names of the structs deriving from base, in
+// the order of types.h
+func derivedStructs(info *types.TypeInfo, base *types.Struct) []string {
+	var names []string
+	for i := len(info.Structs) - 1; i >= 0; i-- {
+		if info.Structs[i].Base == base {
+			names = append(names, info.Structs[i].Name)
+		}
+	}
+	return names
+}
+
+// memberDocComment documents a member with its JSON key and pointer, the
+// inferred JSON type, its optionality and an example value from the samples.
+func memberDocComment(f *types.Field) string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("    /// JSON key %s at `%s`\n", strconv.Quote(f.JSONName), commentText(f.Path)))
+
+	kind := types.DescribeJSONType(f)
+	if f.Format != types.FormatDefault {
+		kind += " (" + f.Format.String() + ")"
+	}
+	var presence []string
+	if f.IsOptional {
+		presence = append(presence, "optional")
+	}
+	if f.Nullable {
+		presence = append(presence, "nullable")
+	}
+	if len(presence) == 0 {
+		presence = append(presence, "required")
+	}
+	b.WriteString(fmt.Sprintf("    /// Type: %s, %s\n", kind, strings.Join(presence, ", ")))
+
+	if len(f.Examples) > 0 {
+		b.WriteString(fmt.Sprintf("    /// Example: %s\n", truncateExample(f.Examples[0])))
+	}
+	return b.String()
+}
+
+// memberComments returns the comment lines placed above a member: its doc
+// comment, then with --provenance where it came from
+func (g *AdapterGenerator) memberComments(f *types.Field) string {
+	var comments string
+	if g.docComments {
+		comments += memberDocComment(f)
+	}
+	if g.provenance {
+		comments += provenanceComment(f)
+	}
+	return comments
+}
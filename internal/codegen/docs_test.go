@@ -0,0 +1,134 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestStructDocComment(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       *types.Struct
+		derived []string
+		want    string
+	}{
+		{"root", &types.Struct{Name: "Root"}, nil, "/// JSON object at the document root.\n"},
+		{"nested", &types.Struct{Name: "Address", Path: "/user/address"}, nil,
+			"/// JSON object at `/user/address`.\n"},
+		{"extracted base", &types.Struct{Name: "Audit", Path: "/user"}, []string{"User", "Order"},
+			"/// Members shared by User, Order.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := structDocComment(tt.s, tt.derived); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDerivedStructs(t *testing.T) {
+	base := &types.Struct{Name: "Audit"}
+	info := &types.TypeInfo{Structs: []*types.Struct{
+		{Name: "Root"}, {Name: "Order", Base: base}, {Name: "User", Base: base}, base,
+	}}
+	if got := strings.Join(derivedStructs(info, base), ", "); got != "User, Order" {
+		t.Errorf("got %q, want types.h order %q", got, "User, Order")
+	}
+	if got := derivedStructs(info, &types.Struct{Name: "Other"}); got != nil {
+		t.Errorf("got %v for a struct nothing derives from", got)
+	}
+}
+
+func TestMemberDocComment(t *testing.T) {
+	tests := []struct {
+		name  string
+		field types.Field
+		want  string
+	}{
+		{"required with example",
+			types.Field{JSONName: "id", Path: "/id", Type: types.JSONInt, Examples: []string{"42"}},
+			"    /// JSON key \"id\" at `/id`\n    /// Type: int, required\n    /// Example: 42\n"},
+		{"optional and nullable, no example",
+			types.Field{JSONName: "note", Path: "/note", Type: types.JSONString, IsOptional: true, Nullable: true},
+			"    /// JSON key \"note\" at `/note`\n    /// Type: string, optional, nullable\n"},
+		{"format",
+			types.Field{JSONName: "price", Path: "/price", Type: types.JSONFloat, Format: types.FormatDecimal},
+			"    /// Type: float (decimal), required\n"},
+		{"array element type",
+			types.Field{JSONName: "ids", Path: "/ids", Type: types.JSONArray, ElemType: types.JSONInt, ElemNullable: true},
+			"    /// Type: array of nullable int, required\n"},
+		// Keys are Go-quoted so that control characters stay on one line
+		{"key with quote and newline",
+			types.Field{JSONName: "a\"b\nc", Path: "/a\"b\nc", Type: types.JSONBool},
+			"    /// JSON key \"a\\\"b\\nc\" at `"},
+		{"newline in the pointer",
+			types.Field{JSONName: "a\nb", Path: "/a\nb", Type: types.JSONBool},
+			"    /// JSON key \"a\\nb\" at `/a\\nb`\n"},
+		{"long example truncated",
+			types.Field{JSONName: "s", Path: "/s", Type: types.JSONString, Examples: []string{`"` + strings.Repeat("x", 100) + `\\"`}},
+			"    /// Example: \"" + strings.Repeat("x", maxExampleLength-1) + "...\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := tt.field
+			checkOutput(t, memberDocComment(&field), []string{tt.want}, nil)
+		})
+	}
+}
+
+func TestCommentText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/plain/path", "/plain/path"},
+		{"/a\nb", `/a\nb`},
+		{"tab\tand\rreturn", `tab\tand\rreturn`},
+		{"\x00\x1f\x7f", `\x00\x1f\x7f`},
+		{"\u0085", `\u0085`},
+		// Only control characters change; quotes, backslashes and non-ASCII
+		// text stay as they are
+		{`"q" \ café`, `"q" \ café`},
+	}
+	for _, tt := range tests {
+		if got := commentText(tt.in); got != tt.want {
+			t.Errorf("commentText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// Keys holding control characters stay on the comment line
+func TestCommentsEscapeControlCharacters(t *testing.T) {
+	f := &types.Field{JSONName: "a\nb", Path: "/a\nb", Type: types.JSONString,
+		Pointers: []string{"/a\nb"}, Sources: []string{"in\n.json"}, Examples: []string{`"x\ny"`}}
+	for _, comment := range []string{memberDocComment(f), provenanceComment(f)} {
+		for _, line := range strings.SplitAfter(strings.TrimSuffix(comment, "\n"), "\n") {
+			if !strings.HasPrefix(line, "    //") {
+				t.Errorf("line %q outside the comment in\n%s", line, comment)
+			}
+		}
+	}
+	checkOutput(t, provenanceComment(f), []string{"    // from in\\n.json  [/a\\nb]\n"}, nil)
+}
+
+// The doc comment comes before the provenance comment
+func TestMemberComments(t *testing.T) {
+	f := &types.Field{JSONName: "id", Path: "/id", Type: types.JSONInt, Pointers: []string{"/id"}}
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{}, ""},
+		{Config{DocComments: true}, memberDocComment(f)},
+		{Config{Provenance: true}, provenanceComment(f)},
+		{Config{DocComments: true, Provenance: true}, memberDocComment(f) + provenanceComment(f)},
+	}
+	for _, tt := range tests {
+		if got := NewAdapterGenerator(tt.cfg, "").memberComments(f); got != tt.want {
+			t.Errorf("memberComments(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
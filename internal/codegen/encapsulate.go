@@ -0,0 +1,196 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"json2cpp/internal/types"
+)
+
+// serializerAccess is the struct befriended by the generated classes. With
+// --classes the serializer implementation defines it with the bodies of the
+// serialization functions as static members, and the declared functions
+// forward to them.
+const serializerAccess = "SerializerAccess"
+
+// Default accessor naming patterns; {name} is the member name and {Name} the
+// same with its first letter upper-cased.
+const (
+	DefaultGetterPattern = "{name}"
+	DefaultSetterPattern = "set_{name}"
+)
+
+// memberName returns the C++ name of the member holding a field; private
+// members of classes get a trailing underscore
+func (g *AdapterGenerator) memberName(f *types.Field) string {
+	name := g.getFieldName(f.Name)
+	if g.encapsulate {
+		return name + "_"
+	}
+	return name
+}
+
+// memberAccess returns the expression reading a field of obj from outside
+// the type: the member itself, or its getter in a class
+func (g *AdapterGenerator) memberAccess(f *types.Field, obj string) string {
+	if g.encapsulate {
+		return fmt.Sprintf("%s.%s()", obj, g.getterName(f))
+	}
+	return obj + "." + g.memberName(f)
+}
+
+// accessorName applies a naming pattern to the member name of a field
+func (g *AdapterGenerator) accessorName(pattern string, f *types.Field) string {
+	name := g.getFieldName(f.Name)
+	capitalized := strings.ToUpper(name[:1]) + name[1:]
+	return strings.NewReplacer("{name}", name, "{Name}", capitalized).Replace(pattern)
+}
+
+func (g *AdapterGenerator) getterName(f *types.Field) string {
+	return g.accessorName(g.getterPattern, f)
+}
+
+func (g *AdapterGenerator) setterName(f *types.Field) string {
+	return g.accessorName(g.setterPattern, f)
+}
+
+// ValidateAccessorPattern checks that an accessor naming pattern names the
+// member
+func ValidateAccessorPattern(pattern string) error {
+	if !strings.Contains(pattern, "{name}") && !strings.Contains(pattern, "{Name}") {
+		return fmt.Errorf("accessor pattern %q contains neither {name} nor {Name}", pattern)
+	}
+	return nil
+}
+
+// passedByValue reports whether a member is cheap enough to be returned and
+// set by value rather than by const reference
+func (g *AdapterGenerator) passedByValue(f *types.Field) bool {
+	switch g.defaultKindOf(f) {
+	case defaultBool, defaultInt, defaultUnsigned, defaultFloat:
+		return true
+	default:
+		return false
+	}
+}
+
+// generateAccessors generates the getter, setters and, for Optional and
+// Nullable members, has_x() of a class member
+func (g *AdapterGenerator) generateAccessors(f *types.Field) (string, error) {
+	memberType, err := g.getCppType(f)
+	if err != nil {
+		return "", err
+	}
+	member := g.memberName(f)
+	getter := g.getterName(f)
+	setter := g.setterName(f)
+
+	var buf bytes.Buffer
+	if g.isOptionalMember(f) || g.isNullableMember(f) {
+		buf.WriteString(fmt.Sprintf("    bool has_%s() const { return %s.has_value(); }\n", g.getFieldName(f.Name), member))
+	}
+	if g.passedByValue(f) {
+		buf.WriteString(fmt.Sprintf("    %s %s() const { return %s; }\n", memberType, getter, member))
+		buf.WriteString(fmt.Sprintf("    void %s(%s value) { %s = value; }\n", setter, memberType, member))
+		return buf.String(), nil
+	}
+	buf.WriteString(fmt.Sprintf("    const %s& %s() const { return %s; }\n", memberType, getter, member))
+	buf.WriteString(fmt.Sprintf("    void %s(const %s& value) { %s = value; }\n", setter, memberType, member))
+	if !g.legacyCpp {
+		buf.WriteString(fmt.Sprintf("    void %s(%s&& value) { %s = std::move(value); }\n", setter, memberType, member))
+	}
+	return buf.String(), nil
+}
+
+// generateClass generates a class with private members and public accessors
+// (--classes). The serializers reach the members through serializerAccess.
+func (g *AdapterGenerator) generateClass(s *types.Struct) (string, error) {
+	var buf bytes.Buffer
+
+	if s.Base != nil {
+		buf.WriteString(fmt.Sprintf("class %s : public %s {\n", s.Name, s.Base.Name))
+	} else {
+		buf.WriteString(fmt.Sprintf("class %s {\n", s.Name))
+	}
+
+	// The public section is left out when there is nothing in it
+	var public bytes.Buffer
+	// Default constructor for C++03 compatibility
+	if g.legacyCpp {
+		public.WriteString(g.generateDefaultConstructor(s))
+	}
+
+	for _, f := range s.Fields {
+		if public.Len() > 0 {
+			public.WriteString("\n")
+		}
+		accessors, err := g.generateAccessors(f)
+		if err != nil {
+			return "", err
+		}
+		public.WriteString(accessors)
+	}
+
+	if g.equality && g.cppStandard >= 20 {
+		if public.Len() > 0 {
+			public.WriteString("\n")
+		}
+		public.WriteString(g.defaultedComparisons(s.Name))
+	}
+
+	if public.Len() > 0 {
+		buf.WriteString("public:\n")
+		buf.Write(public.Bytes())
+		buf.WriteString("\n")
+	}
+	buf.WriteString("private:\n")
+	buf.WriteString(fmt.Sprintf("    friend struct %s;\n", serializerAccess))
+	if len(s.Fields) > 0 {
+		buf.WriteString("\n")
+	}
+	for _, f := range s.Fields {
+		member, err := g.generateMember(f)
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(g.memberComments(f))
+		buf.WriteString("    " + member + "\n")
+	}
+
+	buf.WriteString("};\n")
+
+	return g.withComparisons(s, buf.String()), nil
+}
+
+// generateSerializerAccess wraps the serialization functions of the structs
+// as static members of serializerAccess, followed by the declared functions
+// forwarding to them
+func (g *AdapterGenerator) generateSerializerAccess(structs []*types.Struct, functions []string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString(fmt.Sprintf("// %s reads and writes the private members of the classes in types.h\n", serializerAccess))
+	buf.WriteString(fmt.Sprintf("struct %s {\n", serializerAccess))
+	for i, code := range functions {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indentCode("static " + code))
+	}
+	buf.WriteString("};\n")
+
+	serializeArgs := "obj, json"
+	if g.parser == ParserRapidJSON {
+		serializeArgs = "obj, json, allocator"
+	}
+	for _, s := range structs {
+		buf.WriteString("\n")
+		buf.WriteString(strings.TrimSuffix(g.generateDeserializeFunctionDecl(s), ";") + " {\n")
+		buf.WriteString(fmt.Sprintf("    %s::Deserialize%s(obj, json);\n", serializerAccess, s.Name))
+		buf.WriteString("}\n\n")
+		buf.WriteString(strings.TrimSuffix(g.generateSerializeFunctionDecl(s), ";") + " {\n")
+		buf.WriteString(fmt.Sprintf("    %s::Serialize%s(%s);\n", serializerAccess, s.Name, serializeArgs))
+		buf.WriteString("}\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,183 @@
+package codegen
+
+import (
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestValidateAccessorPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"{name}", false},
+		{"get{Name}", false},
+		{"set_{name}", false},
+		{"getValue", true},
+		{"{NAME}", true},
+		{"", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateAccessorPattern(tt.pattern); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateAccessorPattern(%q) error = %v, wantErr %v", tt.pattern, err, tt.wantErr)
+		}
+	}
+}
+
+func TestAccessorName(t *testing.T) {
+	f := &types.Field{Name: "user_id"}
+	tests := []struct {
+		name      string
+		camelCase bool
+		pattern   string
+		want      string
+	}{
+		{"default getter", false, DefaultGetterPattern, "user_id"},
+		{"default setter", false, DefaultSetterPattern, "set_user_id"},
+		{"capitalized", false, "get{Name}", "getUser_id"},
+		{"camelCase member", true, "set{Name}", "setUserId"},
+		{"both placeholders", false, "{name}_or_{Name}", "user_id_or_User_id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewAdapterGenerator(Config{CamelCase: tt.camelCase}, "")
+			if got := g.accessorName(tt.pattern, f); got != tt.want {
+				t.Errorf("accessorName(%q) = %q, want %q", tt.pattern, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemberAccess(t *testing.T) {
+	f := &types.Field{Name: "id"}
+	tests := []struct {
+		cfg        Config
+		wantMember string
+		wantAccess string
+	}{
+		{Config{}, "id", "obj.id"},
+		{Config{Encapsulate: true}, "id_", "obj.id()"},
+		{Config{Encapsulate: true, GetterPattern: "get{Name}"}, "id_", "obj.getId()"},
+	}
+	for _, tt := range tests {
+		g := NewAdapterGenerator(tt.cfg, "")
+		if got := g.memberName(f); got != tt.wantMember {
+			t.Errorf("memberName = %q, want %q", got, tt.wantMember)
+		}
+		if got := g.memberAccess(f, "obj"); got != tt.wantAccess {
+			t.Errorf("memberAccess = %q, want %q", got, tt.wantAccess)
+		}
+	}
+}
+
+func TestGenerateAccessors(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   Config
+		field types.Field
+		want  string
+	}{
+		{"scalar by value", Config{},
+			types.Field{Name: "count", Type: types.JSONInt},
+			"    int64_t count() const { return count_; }\n" +
+				"    void set_count(int64_t value) { count_ = value; }\n"},
+		{"string by reference with move", Config{},
+			types.Field{Name: "name", Type: types.JSONString},
+			"    const std::string& name() const { return name_; }\n" +
+				"    void set_name(const std::string& value) { name_ = value; }\n" +
+				"    void set_name(std::string&& value) { name_ = std::move(value); }\n"},
+		{"no move before C++11", Config{LegacyCPP: true},
+			types.Field{Name: "name", Type: types.JSONString},
+			"    const std::string& name() const { return name_; }\n" +
+				"    void set_name(const std::string& value) { name_ = value; }\n"},
+		// Decimal is a class, not a scalar, despite its numeric kind
+		{"Decimal by reference", Config{},
+			types.Field{Name: "price", Type: types.JSONFloat, Format: types.FormatDecimal},
+			"    const Decimal& price() const { return price_; }\n" +
+				"    void set_price(const Decimal& value) { price_ = value; }\n" +
+				"    void set_price(Decimal&& value) { price_ = std::move(value); }\n"},
+		{"Optional has has_x", Config{OptionalNull: true, CppStandard: 17},
+			types.Field{Name: "age", Type: types.JSONInt, IsOptional: true},
+			"    bool has_age() const { return age_.has_value(); }\n" +
+				"    const Optional<int64_t>& age() const { return age_; }\n"},
+		{"has_x follows the member name, not the pattern", Config{Nullable: true, GetterPattern: "get{Name}"},
+			types.Field{Name: "note", Type: types.JSONString, Nullable: true},
+			"    bool has_note() const { return note_.has_value(); }\n" +
+				"    const Nullable<std::string>& getNote() const { return note_; }\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Encapsulate = true
+			field := tt.field
+			out, err := NewAdapterGenerator(cfg, "").generateAccessors(&field)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, out, []string{tt.want}, nil)
+		})
+	}
+}
+
+func TestGenerateClass(t *testing.T) {
+	base := &types.Struct{Name: "Audit"}
+	tests := []struct {
+		name    string
+		cfg     Config
+		s       *types.Struct
+		want    []string
+		notWant []string
+	}{
+		{"empty class has no public section", Config{}, &types.Struct{Name: "E"},
+			[]string{"class E {\nprivate:\n    friend struct SerializerAccess;\n};\n"}, nil},
+		{"members private after accessors", Config{},
+			&types.Struct{Name: "P", Base: base, Fields: []*types.Field{{Name: "x", Type: types.JSONInt}}},
+			[]string{"class P : public Audit {\npublic:\n" +
+				"    int64_t x() const { return x_; }\n" +
+				"    void set_x(int64_t value) { x_ = value; }\n\n" +
+				"private:\n    friend struct SerializerAccess;\n\n" +
+				"    int64_t x_ = 0;\n};\n"}, nil},
+		{"C++03 constructor initializes the private member", Config{LegacyCPP: true},
+			&types.Struct{Name: "P", Fields: []*types.Field{{Name: "x", Type: types.JSONInt}}},
+			[]string{"public:\n    P() : x_(0) {}\n\n    int64_t x() const", "    int64_t x_;\n"}, nil},
+		{"C++20 defaulted comparisons are public", Config{CppStandard: 20, Equality: true}, &types.Struct{Name: "E"},
+			[]string{"public:\n    bool operator==(const E&) const = default;\n\nprivate:\n"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Encapsulate = true
+			out, err := NewAdapterGenerator(cfg, "").generateClass(tt.s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, out, tt.want, tt.notWant)
+		})
+	}
+}
+
+func TestGenerateSerializerAccess(t *testing.T) {
+	structs := []*types.Struct{{Name: "Root"}}
+	functions := []string{"void DeserializeRoot(Root& obj) {\n}\n"}
+
+	tests := []struct {
+		parser ParserType
+		want   string
+	}{
+		{ParserRapidJSON, "    SerializerAccess::SerializeRoot(obj, json, allocator);\n"},
+		{ParserNlohmann, "    SerializerAccess::SerializeRoot(obj, json);\n"},
+		{ParserJsonCpp, "    SerializerAccess::SerializeRoot(obj, json);\n"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.parser), func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser, Encapsulate: true}, "")
+			out := g.generateSerializerAccess(structs, functions)
+			checkOutput(t, out, []string{
+				"struct SerializerAccess {\n    static void DeserializeRoot(Root& obj) {\n    }\n};\n",
+				"    SerializerAccess::DeserializeRoot(obj, json);\n",
+				tt.want,
+			}, nil)
+		})
+	}
+}
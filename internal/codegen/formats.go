@@ -0,0 +1,474 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"json2cpp/internal/types"
+)
+
+// decimalTypeDefinition is emitted into types.h when a field uses
+// types.FormatDecimal. It only needs the standard library.
+const decimalTypeDefinition = `// Exact fixed-point decimal: value = mantissa * 10^-scale.
+// Serializers read it from the raw number text where the JSON library keeps
+// it (rapidjson with kParseNumbersAsStringsFlag, numbers sent as strings) and
+// from integers exactly; other numbers, which the library already holds as a
+// double, give their shortest round-trip digits. They always write a JSON
+// number: integers exactly, other values through ToDouble(), which the JSON
+// libraries print with the same digits when FitsDouble() holds.
+struct Decimal {
+    int64_t mantissa;
+    int scale;
+
+    Decimal() : mantissa(0), scale(0) {}
+    Decimal(int64_t m, int s) : mantissa(m), scale(s) {}
+
+    // Parse decimal text such as "-19.99" or "1.5e3". Returns false for
+    // malformed text or more than 18 significant digits.
+    static bool Parse(const std::string& text, Decimal& out) {
+        size_t i = 0;
+        bool negative = false;
+        if (i < text.size() && (text[i] == '-' || text[i] == '+')) {
+            negative = text[i] == '-';
+            ++i;
+        }
+        int64_t mantissa = 0;
+        int scale = 0;
+        int digits = 0;
+        bool seenDigit = false;
+        bool seenPoint = false;
+        for (; i < text.size(); ++i) {
+            char c = text[i];
+            if (c >= '0' && c <= '9') {
+                seenDigit = true;
+                if ((digits > 0 || c != '0') && ++digits > 18) {
+                    return false;
+                }
+                mantissa = mantissa * 10 + (c - '0');
+                if (seenPoint) {
+                    ++scale;
+                }
+            } else if (c == '.' && !seenPoint) {
+                seenPoint = true;
+            } else {
+                break;
+            }
+        }
+        if (!seenDigit) {
+            return false;
+        }
+        if (i < text.size() && (text[i] == 'e' || text[i] == 'E')) {
+            ++i;
+            bool negativeExponent = false;
+            if (i < text.size() && (text[i] == '-' || text[i] == '+')) {
+                negativeExponent = text[i] == '-';
+                ++i;
+            }
+            int exponent = 0;
+            bool seenExponentDigit = false;
+            for (; i < text.size() && text[i] >= '0' && text[i] <= '9'; ++i) {
+                seenExponentDigit = true;
+                exponent = exponent * 10 + (text[i] - '0');
+                if (exponent > 400) {
+                    return false;
+                }
+            }
+            if (!seenExponentDigit) {
+                return false;
+            }
+            scale += negativeExponent ? exponent : -exponent;
+        }
+        if (i != text.size()) {
+            return false;
+        }
+        for (; scale < 0; ++scale) {
+            if (mantissa != 0 && ++digits > 18) {
+                return false;
+            }
+            mantissa *= 10;
+        }
+        out.mantissa = negative ? -mantissa : mantissa;
+        out.scale = scale;
+        return true;
+    }
+
+    // FromDouble recovers the shortest decimal text that round-trips to value
+    static Decimal FromDouble(double value) {
+        char buf[32];
+        for (int precision = 1; precision <= 17; ++precision) {
+            std::sprintf(buf, "%.*g", precision, value);
+            if (std::strtod(buf, NULL) == value) {
+                break;
+            }
+        }
+        Decimal result;
+        Parse(buf, result);
+        return result;
+    }
+
+    std::string ToString() const {
+        uint64_t magnitude = mantissa < 0 ? 0 - static_cast<uint64_t>(mantissa) : static_cast<uint64_t>(mantissa);
+        std::string text;
+        do {
+            text.insert(text.begin(), static_cast<char>('0' + magnitude % 10));
+            magnitude /= 10;
+        } while (magnitude > 0);
+        if (scale > 0) {
+            size_t fraction = static_cast<size_t>(scale);
+            if (text.size() <= fraction) {
+                text.insert(0, fraction - text.size() + 1, '0');
+            }
+            text.insert(text.size() - fraction, ".");
+        }
+        return mantissa < 0 ? "-" + text : text;
+    }
+
+    // ToDouble returns the double nearest to the exact value
+    double ToDouble() const {
+        return std::strtod(ToString().c_str(), NULL);
+    }
+
+    // FitsDouble reports whether the shortest digits of ToDouble() give back
+    // the exact value, so that it can be written as a JSON number
+    bool FitsDouble() const {
+        Decimal a = Normalized();
+        Decimal b = FromDouble(ToDouble()).Normalized();
+        return a.mantissa == b.mantissa && a.scale == b.scale;
+    }
+
+    // Normalized drops trailing fraction zeros: 1.50 becomes 1.5
+    Decimal Normalized() const {
+        Decimal result = *this;
+        while (result.scale > 0 && result.mantissa % 10 == 0) {
+            result.mantissa /= 10;
+            --result.scale;
+        }
+        return result;
+    }
+};
+`
+
+// base64Helpers are emitted into the serializer implementation when a field
+// uses types.FormatBase64 or types.FormatBase64URL.
+const base64Helpers = `// Base64 helpers (RFC 4648). The URL-safe alphabet is written unpadded;
+// both alphabets are accepted with or without padding.
+static std::string EncodeBase64(const std::vector<uint8_t>& data, bool urlSafe) {
+    const char* alphabet = urlSafe
+        ? "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+        : "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/";
+    std::string out;
+    out.reserve((data.size() + 2) / 3 * 4);
+    size_t i = 0;
+    for (; i + 2 < data.size(); i += 3) {
+        uint32_t n = (static_cast<uint32_t>(data[i]) << 16) | (static_cast<uint32_t>(data[i + 1]) << 8) | data[i + 2];
+        out += alphabet[(n >> 18) & 63];
+        out += alphabet[(n >> 12) & 63];
+        out += alphabet[(n >> 6) & 63];
+        out += alphabet[n & 63];
+    }
+    if (i + 1 == data.size()) {
+        uint32_t n = static_cast<uint32_t>(data[i]) << 16;
+        out += alphabet[(n >> 18) & 63];
+        out += alphabet[(n >> 12) & 63];
+        if (!urlSafe) {
+            out += "==";
+        }
+    } else if (i + 2 == data.size()) {
+        uint32_t n = (static_cast<uint32_t>(data[i]) << 16) | (static_cast<uint32_t>(data[i + 1]) << 8);
+        out += alphabet[(n >> 18) & 63];
+        out += alphabet[(n >> 12) & 63];
+        out += alphabet[(n >> 6) & 63];
+        if (!urlSafe) {
+            out += '=';
+        }
+    }
+    return out;
+}
+
+// DecodeBase64 returns false when text is not valid base64 in the alphabet
+static bool DecodeBase64(const std::string& text, bool urlSafe, std::vector<uint8_t>& out) {
+    out.clear();
+    size_t end = text.size();
+    while (end > 0 && text[end - 1] == '=') {
+        --end;
+    }
+    if (text.size() - end > 2 || end % 4 == 1 || (end != text.size() && text.size() % 4 != 0)) {
+        return false;
+    }
+    uint32_t buffer = 0;
+    int bits = 0;
+    for (size_t i = 0; i < end; ++i) {
+        char c = text[i];
+        uint32_t value;
+        if (c >= 'A' && c <= 'Z') {
+            value = static_cast<uint32_t>(c - 'A');
+        } else if (c >= 'a' && c <= 'z') {
+            value = static_cast<uint32_t>(c - 'a' + 26);
+        } else if (c >= '0' && c <= '9') {
+            value = static_cast<uint32_t>(c - '0' + 52);
+        } else if (c == (urlSafe ? '-' : '+')) {
+            value = 62;
+        } else if (c == (urlSafe ? '_' : '/')) {
+            value = 63;
+        } else {
+            return false;
+        }
+        buffer = (buffer << 6) | value;
+        bits += 6;
+        if (bits >= 8) {
+            bits -= 8;
+            out.push_back(static_cast<uint8_t>((buffer >> bits) & 0xFF));
+        }
+    }
+    return true;
+}
+`
+
+// numericStringHelpers are emitted into the serializer implementation when a
+// field uses types.FormatIntString or types.FormatFloatString.
+const numericStringHelpers = `// Numeric string helpers for numbers carried as JSON strings ("42", "0.75").
+// Formatting gives the shortest text that parses back to the same value, so
+// canonical input is written back unchanged.
+static bool ParseInt64String(const std::string& text, int64_t& out) {
+    size_t i = 0;
+    bool negative = false;
+    if (i < text.size() && text[i] == '-') {
+        negative = true;
+        ++i;
+    }
+    if (i == text.size()) {
+        return false;
+    }
+    const uint64_t limit = (~static_cast<uint64_t>(0) >> 1) + (negative ? 1 : 0);
+    uint64_t magnitude = 0;
+    for (; i < text.size(); ++i) {
+        char c = text[i];
+        if (c < '0' || c > '9') {
+            return false;
+        }
+        uint64_t digit = static_cast<uint64_t>(c - '0');
+        if (magnitude > (limit - digit) / 10) {
+            return false;
+        }
+        magnitude = magnitude * 10 + digit;
+    }
+    out = negative ? static_cast<int64_t>(0 - magnitude) : static_cast<int64_t>(magnitude);
+    return true;
+}
+
+static std::string FormatInt64String(int64_t value) {
+    uint64_t magnitude = value < 0 ? 0 - static_cast<uint64_t>(value) : static_cast<uint64_t>(value);
+    std::string text;
+    do {
+        text.insert(text.begin(), static_cast<char>('0' + magnitude % 10));
+        magnitude /= 10;
+    } while (magnitude > 0);
+    return value < 0 ? "-" + text : text;
+}
+
+static bool ParseDoubleString(const std::string& text, double& out) {
+    if (text.empty()) {
+        return false;
+    }
+    const char* begin = text.c_str();
+    char* end = NULL;
+    errno = 0;
+    double value = std::strtod(begin, &end);
+    if (end != begin + text.size() || errno == ERANGE) {
+        return false;
+    }
+    out = value;
+    return true;
+}
+
+static std::string FormatDoubleString(double value) {
+    char buf[32];
+    for (int precision = 1; precision <= 17; ++precision) {
+        std::sprintf(buf, "%.*g", precision, value);
+        if (std::strtod(buf, NULL) == value) {
+            break;
+        }
+    }
+    return buf;
+}
+`
+
+// usesBase64 reports whether any field in info is base64 encoded
+func usesBase64(info *types.TypeInfo) bool {
+	return usesFormat(info, types.FormatBase64) || usesFormat(info, types.FormatBase64URL)
+}
+
+// usesNumericStrings reports whether any field in info holds a number as a
+// JSON string
+func usesNumericStrings(info *types.TypeInfo) bool {
+	return usesFormat(info, types.FormatIntString) || usesFormat(info, types.FormatFloatString)
+}
+
+// usesFormat reports whether any field in info uses the given format
+func usesFormat(info *types.TypeInfo, format types.Format) bool {
+	for _, s := range info.Structs {
+		for _, f := range s.Fields {
+			if f.Format == format {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// generateDeserializeFormatted generates deserialization code for a field
+// with a representation override
+func (g *AdapterGenerator) generateDeserializeFormatted(f *types.Field) (string, error) {
+	var buf bytes.Buffer
+	member := g.targetRef(f)
+	jsonName := f.JSONName
+
+	switch f.Format {
+	case types.FormatDecimal:
+		switch g.parser {
+		case ParserRapidJSON:
+			buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\")) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("        const rapidjson::Value& value = json[\"%s\"];\n", jsonName))
+			buf.WriteString("        if (value.IsString()) {\n")
+			buf.WriteString(fmt.Sprintf("            Decimal::Parse(std::string(value.GetString(), value.GetStringLength()), %s);\n", member))
+			buf.WriteString("        } else if (value.IsInt64()) {\n")
+			buf.WriteString(fmt.Sprintf("            %s = Decimal(value.GetInt64(), 0);\n", member))
+			buf.WriteString("        } else if (value.IsNumber()) {\n")
+			buf.WriteString(fmt.Sprintf("            %s = Decimal::FromDouble(value.GetDouble());\n", member))
+			buf.WriteString("        }\n")
+			buf.WriteString("    }\n")
+		case ParserNlohmann:
+			buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\")) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("        const nlohmann::json& value = json[\"%s\"];\n", jsonName))
+			buf.WriteString("        if (value.is_string()) {\n")
+			buf.WriteString(fmt.Sprintf("            Decimal::Parse(value.get<std::string>(), %s);\n", member))
+			buf.WriteString("        } else if (value.is_number_integer()) {\n")
+			buf.WriteString(fmt.Sprintf("            %s = Decimal(value.get<int64_t>(), 0);\n", member))
+			buf.WriteString("        } else if (value.is_number()) {\n")
+			buf.WriteString(fmt.Sprintf("            %s = Decimal::FromDouble(value.get<double>());\n", member))
+			buf.WriteString("        }\n")
+			buf.WriteString("    }\n")
+		case ParserJsonCpp:
+			buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\")) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("        const Json::Value& value = json[\"%s\"];\n", jsonName))
+			buf.WriteString("        if (value.isString()) {\n")
+			buf.WriteString(fmt.Sprintf("            Decimal::Parse(value.asString(), %s);\n", member))
+			buf.WriteString("        } else if (value.isInt64()) {\n")
+			buf.WriteString(fmt.Sprintf("            %s = Decimal(value.asInt64(), 0);\n", member))
+			buf.WriteString("        } else if (value.isDouble()) {\n")
+			buf.WriteString(fmt.Sprintf("            %s = Decimal::FromDouble(value.asDouble());\n", member))
+			buf.WriteString("        }\n")
+			buf.WriteString("    }\n")
+		}
+
+	case types.FormatBase64, types.FormatBase64URL:
+		urlSafe := f.Format == types.FormatBase64URL
+		buf.WriteString(fmt.Sprintf("    if (%s) {\n", g.stringMemberCheck(jsonName)))
+		buf.WriteString(fmt.Sprintf("        if (!DecodeBase64(%s, %t, %s)) {\n", g.stringMemberValue(jsonName), urlSafe, member))
+		buf.WriteString(fmt.Sprintf("            throw std::invalid_argument(\"invalid %s in \\\"%s\\\"\");\n", f.Format, jsonName))
+		buf.WriteString("        }\n")
+		buf.WriteString("    }\n")
+
+	case types.FormatIntString, types.FormatFloatString:
+		parse := "ParseInt64String"
+		if f.Format == types.FormatFloatString {
+			parse = "ParseDoubleString"
+		}
+		var isString, stringValue, numberCheck, numberValue string
+		switch g.parser {
+		case ParserRapidJSON:
+			buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\")) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("        const rapidjson::Value& value = json[\"%s\"];\n", jsonName))
+			isString, stringValue = "value.IsString()", "std::string(value.GetString(), value.GetStringLength())"
+			numberCheck, numberValue = "value.IsInt64()", "value.GetInt64()"
+			if f.Format == types.FormatFloatString {
+				numberCheck, numberValue = "value.IsNumber()", "value.GetDouble()"
+			}
+		case ParserNlohmann:
+			buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\")) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("        const nlohmann::json& value = json[\"%s\"];\n", jsonName))
+			isString, stringValue = "value.is_string()", "value.get<std::string>()"
+			numberCheck, numberValue = "value.is_number_integer()", "value.get<int64_t>()"
+			if f.Format == types.FormatFloatString {
+				numberCheck, numberValue = "value.is_number()", "value.get<double>()"
+			}
+		case ParserJsonCpp:
+			buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\")) {\n", jsonName))
+			buf.WriteString(fmt.Sprintf("        const Json::Value& value = json[\"%s\"];\n", jsonName))
+			isString, stringValue = "value.isString()", "value.asString()"
+			numberCheck, numberValue = "value.isInt64()", "value.asInt64()"
+			if f.Format == types.FormatFloatString {
+				numberCheck, numberValue = "value.isDouble()", "value.asDouble()"
+			}
+		}
+		buf.WriteString(fmt.Sprintf("        if (%s) {\n", isString))
+		buf.WriteString(fmt.Sprintf("            if (!%s(%s, %s)) {\n", parse, stringValue, member))
+		buf.WriteString(fmt.Sprintf("                throw std::invalid_argument(\"invalid %s in \\\"%s\\\"\");\n", f.Format, jsonName))
+		buf.WriteString("            }\n")
+		buf.WriteString(fmt.Sprintf("        } else if (%s) {\n", numberCheck))
+		buf.WriteString("            // also accept the number unquoted\n")
+		buf.WriteString(fmt.Sprintf("            %s = %s;\n", member, numberValue))
+		buf.WriteString("        }\n")
+		buf.WriteString("    }\n")
+
+	default:
+		return "", fmt.Errorf("unknown field format: %v", f.Format)
+	}
+
+	return buf.String(), nil
+}
+
+// generateSerializeFormatted generates serialization code for a field with a
+// representation override
+func (g *AdapterGenerator) generateSerializeFormatted(f *types.Field) (string, error) {
+	var buf bytes.Buffer
+	member := g.memberRef(f)
+	jsonName := f.JSONName
+
+	switch f.Format {
+	case types.FormatDecimal:
+		buf.WriteString(fmt.Sprintf("    if (%s.scale == 0) {\n", member))
+		switch g.parser {
+		case ParserRapidJSON:
+			buf.WriteString(fmt.Sprintf("        json.AddMember(\"%s\", %s.mantissa, allocator);\n", jsonName, member))
+		case ParserNlohmann:
+			buf.WriteString(fmt.Sprintf("        json[\"%s\"] = %s.mantissa;\n", jsonName, member))
+		case ParserJsonCpp:
+			buf.WriteString(fmt.Sprintf("        json[\"%s\"] = static_cast<Json::Int64>(%s.mantissa);\n", jsonName, member))
+		}
+		buf.WriteString("    } else {\n")
+		switch g.parser {
+		case ParserRapidJSON:
+			buf.WriteString(fmt.Sprintf("        json.AddMember(\"%s\", %s.ToDouble(), allocator);\n", jsonName, member))
+		case ParserNlohmann, ParserJsonCpp:
+			buf.WriteString(fmt.Sprintf("        json[\"%s\"] = %s.ToDouble();\n", jsonName, member))
+		}
+		buf.WriteString("    }\n")
+
+	case types.FormatBase64, types.FormatBase64URL:
+		encoded := fmt.Sprintf("EncodeBase64(%s, %t)", member, f.Format == types.FormatBase64URL)
+		switch g.parser {
+		case ParserRapidJSON:
+			buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", rapidjson::Value(%s.c_str(), allocator), allocator);\n", jsonName, encoded))
+		case ParserNlohmann, ParserJsonCpp:
+			buf.WriteString(fmt.Sprintf("    json[\"%s\"] = %s;\n", jsonName, encoded))
+		}
+
+	case types.FormatIntString, types.FormatFloatString:
+		format := "FormatInt64String"
+		if f.Format == types.FormatFloatString {
+			format = "FormatDoubleString"
+		}
+		text := fmt.Sprintf("%s(%s)", format, member)
+		switch g.parser {
+		case ParserRapidJSON:
+			buf.WriteString(fmt.Sprintf("    json.AddMember(\"%s\", rapidjson::Value(%s.c_str(), allocator), allocator);\n", jsonName, text))
+		case ParserNlohmann, ParserJsonCpp:
+			buf.WriteString(fmt.Sprintf("    json[\"%s\"] = %s;\n", jsonName, text))
+		}
+
+	default:
+		return "", fmt.Errorf("unknown field format: %v", f.Format)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,216 @@
+package codegen
+
+import (
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestBase64Members(t *testing.T) {
+	tests := []struct {
+		parser    ParserType
+		format    types.Format
+		wantRead  string
+		wantWrite string
+	}{
+		{ParserRapidJSON, types.FormatBase64,
+			"    if (json.HasMember(\"data\") && json[\"data\"].IsString()) {\n" +
+				"        if (!DecodeBase64(json[\"data\"].GetString(), false, obj.data)) {\n" +
+				"            throw std::invalid_argument(\"invalid base64 in \\\"data\\\"\");\n",
+			"    json.AddMember(\"data\", rapidjson::Value(EncodeBase64(obj.data, false).c_str(), allocator), allocator);\n"},
+		{ParserNlohmann, types.FormatBase64URL,
+			"        if (!DecodeBase64(json[\"data\"].get<std::string>(), true, obj.data)) {\n" +
+				"            throw std::invalid_argument(\"invalid base64url in \\\"data\\\"\");\n",
+			"    json[\"data\"] = EncodeBase64(obj.data, true);\n"},
+		{ParserJsonCpp, types.FormatBase64,
+			"    if (json.isMember(\"data\") && json[\"data\"].isString()) {\n" +
+				"        if (!DecodeBase64(json[\"data\"].asString(), false, obj.data)) {\n",
+			"    json[\"data\"] = EncodeBase64(obj.data, false);\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.parser)+"/"+tt.format.String(), func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser}, "")
+			f := &types.Field{Name: "data", JSONName: "data", Type: types.JSONString, Format: tt.format}
+			if cppType, _ := g.getCppType(f); cppType != "std::vector<uint8_t>" {
+				t.Errorf("C++ type = %s, want std::vector<uint8_t>", cppType)
+			}
+			read, err := g.generateDeserializeFormatted(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, read, []string{tt.wantRead}, nil)
+			write, err := g.generateSerializeFormatted(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, write, []string{tt.wantWrite}, nil)
+		})
+	}
+}
+
+// Decimal reads text exactly (numbers sent as strings, or raw numbers from
+// rapidjson's kParseNumbersAsStringsFlag) and integers exactly, and always
+// writes a JSON number
+func TestDecimalMembers(t *testing.T) {
+	tests := []struct {
+		parser    ParserType
+		wantRead  string
+		wantWrite string
+	}{
+		{ParserRapidJSON,
+			"        if (value.IsString()) {\n" +
+				"            Decimal::Parse(std::string(value.GetString(), value.GetStringLength()), obj.price);\n" +
+				"        } else if (value.IsInt64()) {\n" +
+				"            obj.price = Decimal(value.GetInt64(), 0);\n" +
+				"        } else if (value.IsNumber()) {\n" +
+				"            obj.price = Decimal::FromDouble(value.GetDouble());\n" +
+				"        }\n",
+			"    if (obj.price.scale == 0) {\n" +
+				"        json.AddMember(\"price\", obj.price.mantissa, allocator);\n" +
+				"    } else {\n" +
+				"        json.AddMember(\"price\", obj.price.ToDouble(), allocator);\n" +
+				"    }\n"},
+		{ParserNlohmann,
+			"        if (value.is_string()) {\n" +
+				"            Decimal::Parse(value.get<std::string>(), obj.price);\n" +
+				"        } else if (value.is_number_integer()) {\n" +
+				"            obj.price = Decimal(value.get<int64_t>(), 0);\n" +
+				"        } else if (value.is_number()) {\n" +
+				"            obj.price = Decimal::FromDouble(value.get<double>());\n" +
+				"        }\n",
+			"    if (obj.price.scale == 0) {\n" +
+				"        json[\"price\"] = obj.price.mantissa;\n" +
+				"    } else {\n" +
+				"        json[\"price\"] = obj.price.ToDouble();\n" +
+				"    }\n"},
+		{ParserJsonCpp,
+			"        if (value.isString()) {\n" +
+				"            Decimal::Parse(value.asString(), obj.price);\n" +
+				"        } else if (value.isInt64()) {\n" +
+				"            obj.price = Decimal(value.asInt64(), 0);\n" +
+				"        } else if (value.isDouble()) {\n" +
+				"            obj.price = Decimal::FromDouble(value.asDouble());\n" +
+				"        }\n",
+			"    if (obj.price.scale == 0) {\n" +
+				"        json[\"price\"] = static_cast<Json::Int64>(obj.price.mantissa);\n" +
+				"    } else {\n" +
+				"        json[\"price\"] = obj.price.ToDouble();\n" +
+				"    }\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.parser), func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser}, "")
+			f := &types.Field{Name: "price", JSONName: "price", Type: types.JSONFloat, Format: types.FormatDecimal}
+			if cppType, _ := g.getCppType(f); cppType != "Decimal" {
+				t.Errorf("C++ type = %s, want Decimal", cppType)
+			}
+			read, err := g.generateDeserializeFormatted(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, read, []string{tt.wantRead}, []string{"dump()"})
+			write, err := g.generateSerializeFormatted(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// A value is never turned into a JSON string
+			checkOutput(t, write, []string{tt.wantWrite}, []string{"ToString()", "c_str()"})
+		})
+	}
+}
+
+func TestFormatHelpersOnlyWhenUsed(t *testing.T) {
+	base64 := []string{"#include <stdexcept>\n", base64Helpers}
+	numeric := []string{"#include <stdexcept>\n", "#include <cerrno>\n", numericStringHelpers}
+	for _, format := range []types.Format{types.FormatDefault, types.FormatBase64, types.FormatIntString} {
+		t.Run(format.String(), func(t *testing.T) {
+			s := &types.Struct{Name: "Root", Fields: []*types.Field{
+				{Name: "data", JSONName: "data", Type: types.JSONString, Format: format},
+			}}
+			out, err := NewAdapterGenerator(Config{}, "").generateSerializerImpl(&types.TypeInfo{Structs: []*types.Struct{s}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch format {
+			case types.FormatBase64:
+				checkOutput(t, out, base64, []string{numericStringHelpers})
+			case types.FormatIntString:
+				checkOutput(t, out, numeric, []string{base64Helpers})
+			default:
+				checkOutput(t, out, nil, append(base64, numeric...))
+			}
+		})
+	}
+}
+
+func TestNumericStringMembers(t *testing.T) {
+	tests := []struct {
+		parser  ParserType
+		format  types.Format
+		cppType string
+		want    []string
+	}{
+		{ParserRapidJSON, types.FormatIntString, "int64_t", []string{
+			"            if (!ParseInt64String(std::string(value.GetString(), value.GetStringLength()), obj.n)) {\n" +
+				"                throw std::invalid_argument(\"invalid int-string in \\\"n\\\"\");\n",
+			"        } else if (value.IsInt64()) {\n            // also accept the number unquoted\n            obj.n = value.GetInt64();\n",
+			"    json.AddMember(\"n\", rapidjson::Value(FormatInt64String(obj.n).c_str(), allocator), allocator);\n",
+		}},
+		{ParserNlohmann, types.FormatFloatString, "double", []string{
+			"            if (!ParseDoubleString(value.get<std::string>(), obj.n)) {\n",
+			"        } else if (value.is_number()) {\n            // also accept the number unquoted\n            obj.n = value.get<double>();\n",
+			"    json[\"n\"] = FormatDoubleString(obj.n);\n",
+		}},
+		{ParserJsonCpp, types.FormatIntString, "int64_t", []string{
+			"            if (!ParseInt64String(value.asString(), obj.n)) {\n",
+			"        } else if (value.isInt64()) {\n",
+			"    json[\"n\"] = FormatInt64String(obj.n);\n",
+		}},
+		{ParserJsonCpp, types.FormatFloatString, "double", []string{
+			"        } else if (value.isDouble()) {\n            // also accept the number unquoted\n            obj.n = value.asDouble();\n",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.parser)+"/"+tt.format.String(), func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser}, "")
+			f := &types.Field{Name: "n", JSONName: "n", Type: types.JSONString, Format: tt.format}
+			if cppType, _ := g.getCppType(f); cppType != tt.cppType {
+				t.Errorf("C++ type = %s, want %s", cppType, tt.cppType)
+			}
+			read, err := g.generateDeserializeFormatted(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			write, err := g.generateSerializeFormatted(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, read+write, tt.want, nil)
+		})
+	}
+}
+
+func TestNumericStringDefaultInit(t *testing.T) {
+	g := NewAdapterGenerator(Config{LegacyCPP: true}, "")
+	tests := []struct {
+		format types.Format
+		want   string
+	}{
+		{types.FormatIntString, "0"},
+		{types.FormatFloatString, "0.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format.String(), func(t *testing.T) {
+			f := &types.Field{Name: "n", JSONName: "n", Type: types.JSONString, Format: tt.format}
+			if !g.needsDefaultInit(f) {
+				t.Fatal("numeric string member is not initialized")
+			}
+			if got := g.getDefaultInitValue(f); got != tt.want {
+				t.Errorf("default = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"json2cpp/internal/types"
+)
+
+// hashHelpers are emitted into types.h with --hash. Members are hashed with
+// fixed functions rather than std::hash, so a value hashes the same with every
+// standard library; HashMember falls back to hash_value for other types.
+const hashHelpers = `// Mixes the hash of a member into seed, as boost::hash_combine does.
+inline void HashCombine(std::size_t& seed, std::size_t value) {
+    seed ^= value + 0x9e3779b9 + (seed << 6) + (seed >> 2);
+}
+
+inline std::size_t HashMember(bool value) {
+    return value ? 1 : 0;
+}
+
+inline std::size_t HashMember(int64_t value) {
+    uint64_t bits = static_cast<uint64_t>(value);
+    return static_cast<std::size_t>(bits ^ (bits >> 32));
+}
+
+inline std::size_t HashMember(uint64_t value) {
+    return HashMember(static_cast<int64_t>(value));
+}
+
+// Integer widths of hinted members hash as their 64-bit value
+inline std::size_t HashMember(int8_t value) {
+    return HashMember(static_cast<int64_t>(value));
+}
+
+inline std::size_t HashMember(int16_t value) {
+    return HashMember(static_cast<int64_t>(value));
+}
+
+inline std::size_t HashMember(int32_t value) {
+    return HashMember(static_cast<int64_t>(value));
+}
+
+inline std::size_t HashMember(uint8_t value) {
+    return value;
+}
+
+inline std::size_t HashMember(uint16_t value) {
+    return HashMember(static_cast<int64_t>(value));
+}
+
+inline std::size_t HashMember(uint32_t value) {
+    return HashMember(static_cast<int64_t>(value));
+}
+
+inline std::size_t HashMember(double value) {
+    if (value == 0) {
+        value = 0; // -0.0 == 0.0
+    }
+    uint64_t bits;
+    std::memcpy(&bits, &value, sizeof bits);
+    return HashMember(static_cast<int64_t>(bits));
+}
+
+inline std::size_t HashMember(float value) {
+    return HashMember(static_cast<double>(value));
+}
+
+// FNV-1a
+inline std::size_t HashMember(const std::string& value) {
+    uint64_t hash = 14695981039346656037ULL;
+    for (size_t i = 0; i < value.size(); ++i) {
+        hash ^= static_cast<unsigned char>(value[i]);
+        hash *= 1099511628211ULL;
+    }
+    return HashMember(static_cast<int64_t>(hash));
+}
+`
+
+// cppTemplate is an overloaded C++ function template: its declaration and
+// definition. writeTemplates writes the declarations of all overloads ahead
+// of their definitions, so that they can call one another whatever the
+// nesting of the types.
+type cppTemplate struct {
+	declaration, definition string
+}
+
+// writeTemplates writes the declarations of templates, then their definitions
+func writeTemplates(buf *bytes.Buffer, templates []cppTemplate) {
+	for _, t := range templates {
+		buf.WriteString(t.declaration + ";\n")
+	}
+	for _, t := range templates {
+		buf.WriteString(t.definition)
+	}
+}
+
+// hashGeneric covers generated structs and user types named by hints, which
+// provide hash_value.
+var hashGeneric = cppTemplate{"template <typename T>\nstd::size_t HashMember(const T& value)", `
+template <typename T>
+std::size_t HashMember(const T& value) {
+    return hash_value(value);
+}
+`}
+
+var hashVector = cppTemplate{"template <typename T>\nstd::size_t HashMember(const std::vector<T>& value)", `
+template <typename T>
+std::size_t HashMember(const std::vector<T>& value) {
+    std::size_t seed = value.size();
+    for (size_t i = 0; i < value.size(); ++i) {
+        HashCombine(seed, HashMember(value[i]));
+    }
+    return seed;
+}
+`}
+
+var hashOptional = cppTemplate{"template <typename T>\nstd::size_t HashMember(const Optional<T>& value)", `
+template <typename T>
+std::size_t HashMember(const Optional<T>& value) {
+    std::size_t seed = value.has_value() ? 1 : 0;
+    if (value.has_value()) {
+        HashCombine(seed, HashMember(*value));
+    }
+    return seed;
+}
+`}
+
+var hashNullable = cppTemplate{"template <typename T>\nstd::size_t HashMember(const Nullable<T>& value)", `
+template <typename T>
+std::size_t HashMember(const Nullable<T>& value) {
+    std::size_t seed = static_cast<std::size_t>(value.state());
+    if (value.has_value()) {
+        HashCombine(seed, HashMember(*value));
+    }
+    return seed;
+}
+`}
+
+var hashVariant = cppTemplate{"template <typename... Ts>\nstd::size_t HashMember(const std::variant<Ts...>& value)", `
+template <typename... Ts>
+std::size_t HashMember(const std::variant<Ts...>& value) {
+    std::size_t seed = value.index();
+    std::visit([&seed](const auto& alternative) { HashCombine(seed, HashMember(alternative)); }, value);
+    return seed;
+}
+`}
+
+// hashDecimal hashes the normalized value, as 1.5 == 1.50.
+const hashDecimal = `inline std::size_t HashMember(Decimal value) {
+    while (value.scale > 0 && value.mantissa % 10 == 0) {
+        value.mantissa /= 10;
+        --value.scale;
+    }
+    if (value.mantissa == 0) {
+        value.scale = 0;
+    }
+    std::size_t seed = HashMember(value.mantissa);
+    HashCombine(seed, static_cast<std::size_t>(value.scale));
+    return seed;
+}
+`
+
+// writeHashHelpers writes the member hashing functions used by the generated
+// hash_value functions, for the bundled types in use.
+func (g *AdapterGenerator) writeHashHelpers(buf *bytes.Buffer, info *types.TypeInfo) {
+	buf.WriteString(hashHelpers)
+	buf.WriteString("\n")
+	if usesFormat(info, types.FormatDecimal) {
+		buf.WriteString(hashDecimal)
+		buf.WriteString("\n")
+	}
+
+	templates := []cppTemplate{hashGeneric, hashVector}
+	if g.usesOptional(info) {
+		templates = append(templates, hashOptional)
+	}
+	if g.usesNullable(info) {
+		templates = append(templates, hashNullable)
+	}
+	if g.useVariant() && (hasUnions(info) || usesVariants(info)) {
+		templates = append(templates, hashVariant)
+	}
+	writeTemplates(buf, templates)
+	buf.WriteString("\n")
+}
+
+// generateHashValue returns the free hash_value of a struct, found by
+// boost::hash, combining the base and then each member in declaration order.
+func (g *AdapterGenerator) generateHashValue(s *types.Struct) string {
+	parts := g.comparedParts(s)
+	var buf bytes.Buffer
+	if len(parts) == 0 {
+		buf.WriteString(fmt.Sprintf("inline std::size_t hash_value(const %s&) {\n", s.Name))
+		buf.WriteString("    return 0;\n")
+		buf.WriteString("}\n")
+		return buf.String()
+	}
+	buf.WriteString(fmt.Sprintf("inline std::size_t hash_value(const %s& obj) {\n", s.Name))
+	buf.WriteString("    std::size_t seed = 0;\n")
+	for _, part := range parts {
+		buf.WriteString(fmt.Sprintf("    HashCombine(seed, HashMember(%s));\n", part("obj")))
+	}
+	buf.WriteString("    return seed;\n")
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+// generateStdHash returns the std::hash specializations of the structs,
+// forwarding to hash_value. std::hash needs C++11.
+func (g *AdapterGenerator) generateStdHash(info *types.TypeInfo) string {
+	qualifier := ""
+	if g.namespace != "" {
+		qualifier = g.namespace + "::"
+	}
+	var buf bytes.Buffer
+	buf.WriteString("namespace std {\n")
+	for i := len(info.Structs) - 1; i >= 0; i-- {
+		name := qualifier + info.Structs[i].Name
+		buf.WriteString("\ntemplate <>\n")
+		buf.WriteString(fmt.Sprintf("struct hash<%s> {\n", name))
+		buf.WriteString(fmt.Sprintf("    std::size_t operator()(const %s& obj) const {\n", name))
+		buf.WriteString(fmt.Sprintf("        return %shash_value(obj);\n", qualifier))
+		buf.WriteString("    }\n")
+		buf.WriteString("};\n")
+	}
+	buf.WriteString("\n} // namespace std\n")
+	return buf.String()
+}
@@ -0,0 +1,186 @@
+package codegen
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"json2cpp/internal/hints"
+	"json2cpp/internal/types"
+)
+
+func TestWriteHashHelpers(t *testing.T) {
+	union := &types.Struct{Name: "Shape", Union: &types.Union{Tag: "type"}}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		fields  []*types.Field
+		union   bool
+		want    []cppTemplate
+		notWant []cppTemplate
+	}{
+		{"plain members", Config{},
+			[]*types.Field{{Name: "id", Type: types.JSONInt}}, false,
+			[]cppTemplate{hashGeneric, hashVector},
+			[]cppTemplate{hashOptional, hashNullable, hashVariant}},
+		{"Optional", Config{OptionalNull: true},
+			[]*types.Field{{Name: "id", Type: types.JSONInt, IsOptional: true}}, false,
+			[]cppTemplate{hashGeneric, hashVector, hashOptional},
+			[]cppTemplate{hashNullable}},
+		{"Nullable", Config{Nullable: true},
+			[]*types.Field{{Name: "id", Type: types.JSONInt, Nullable: true}}, false,
+			[]cppTemplate{hashNullable},
+			[]cppTemplate{hashOptional}},
+		{"std::variant union", Config{CppStandard: 17}, nil, true,
+			[]cppTemplate{hashVariant}, nil},
+		{"union before C++17", Config{}, nil, true,
+			nil, []cppTemplate{hashVariant}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: tt.fields}}}
+			if tt.union {
+				info.Structs = append(info.Structs, union)
+			}
+			var buf bytes.Buffer
+			NewAdapterGenerator(tt.cfg, "").writeHashHelpers(&buf, info)
+			out := buf.String()
+
+			// Every declaration comes ahead of the first definition
+			firstDefinition := strings.Index(out, hashGeneric.definition)
+			for _, tmpl := range tt.want {
+				declaration := strings.Index(out, tmpl.declaration+";\n")
+				if declaration < 0 || declaration > firstDefinition {
+					t.Errorf("declaration %q missing or after the definitions", tmpl.declaration)
+				}
+				if !strings.Contains(out, tmpl.definition) {
+					t.Errorf("definition of %q missing", tmpl.declaration)
+				}
+			}
+			for _, tmpl := range tt.notWant {
+				if strings.Contains(out, tmpl.declaration) {
+					t.Errorf("unused %q written", tmpl.declaration)
+				}
+			}
+		})
+	}
+}
+
+// Every scalar type a hint can give a member has its own overload, so that
+// no call is ambiguous between the integer overloads
+func TestHashMemberOverloadPerHintedType(t *testing.T) {
+	for name, cppType := range hints.ScalarTypes {
+		overload := "inline std::size_t HashMember(" + cppType + " value) {"
+		if n := strings.Count(hashHelpers, overload); n != 1 {
+			t.Errorf("%s: %d overloads for %s", name, n, cppType)
+		}
+	}
+}
+
+// Decimal hashes its normalized value, so that 1.5 and 1.50, which compare
+// equal, hash alike
+func TestHashDecimalOnlyWhenUsed(t *testing.T) {
+	for _, format := range []types.Format{types.FormatDefault, types.FormatDecimal} {
+		t.Run(format.String(), func(t *testing.T) {
+			info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: []*types.Field{
+				{Name: "price", Type: types.JSONFloat, Format: format},
+			}}}}
+			var buf bytes.Buffer
+			NewAdapterGenerator(Config{}, "").writeHashHelpers(&buf, info)
+			if got := strings.Contains(buf.String(), hashDecimal); got != (format == types.FormatDecimal) {
+				t.Errorf("Decimal hash written = %v", got)
+			}
+		})
+	}
+}
+
+func TestGenerateHashValue(t *testing.T) {
+	base := &types.Struct{Name: "Audit"}
+	tests := []struct {
+		name string
+		s    *types.Struct
+		want string
+	}{
+		{"no members", &types.Struct{Name: "E"},
+			"inline std::size_t hash_value(const E&) {\n    return 0;\n}\n"},
+		{"base then members", &types.Struct{Name: "User", Base: base, Fields: []*types.Field{
+			{Name: "name", Type: types.JSONString}, {Name: "age", Type: types.JSONInt},
+		}},
+			"inline std::size_t hash_value(const User& obj) {\n" +
+				"    std::size_t seed = 0;\n" +
+				"    HashCombine(seed, HashMember(static_cast<const Audit&>(obj)));\n" +
+				"    HashCombine(seed, HashMember(obj.name));\n" +
+				"    HashCombine(seed, HashMember(obj.age));\n" +
+				"    return seed;\n}\n"},
+		{"tagged union", &types.Struct{Name: "Shape", Union: &types.Union{Tag: "type", Variants: []*types.Variant{
+			{Value: "circle"},
+		}}},
+			"inline std::size_t hash_value(const Shape& obj) {\n" +
+				"    std::size_t seed = 0;\n" +
+				"    HashCombine(seed, HashMember(obj.type));\n" +
+				"    HashCombine(seed, HashMember(obj.circle));\n" +
+				"    return seed;\n}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewAdapterGenerator(Config{Hash: true}, "").generateHashValue(tt.s); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateStdHash(t *testing.T) {
+	// info.Structs holds dependents first; types.h and the specializations
+	// list dependencies first
+	info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root"}, {Name: "Child"}}}
+
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{"", "namespace std {\n\n" +
+			"template <>\nstruct hash<Child> {\n" +
+			"    std::size_t operator()(const Child& obj) const {\n" +
+			"        return hash_value(obj);\n    }\n};\n\n" +
+			"template <>\nstruct hash<Root> {\n" +
+			"    std::size_t operator()(const Root& obj) const {\n" +
+			"        return hash_value(obj);\n    }\n};\n\n" +
+			"} // namespace std\n"},
+		{"api", "namespace std {\n\n" +
+			"template <>\nstruct hash<api::Child> {\n" +
+			"    std::size_t operator()(const api::Child& obj) const {\n" +
+			"        return api::hash_value(obj);\n    }\n};\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run("namespace "+tt.namespace, func(t *testing.T) {
+			out := NewAdapterGenerator(Config{Hash: true, Namespace: tt.namespace}, "").generateStdHash(info)
+			if !strings.HasPrefix(out, tt.want) {
+				t.Errorf("got\n%s\nwant prefix\n%s", out, tt.want)
+			}
+		})
+	}
+}
+
+// std::hash needs C++11; C++03 code gets hash_value only
+func TestStdHashNeedsCpp11(t *testing.T) {
+	info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: []*types.Field{
+		{Name: "id", JSONName: "id", Type: types.JSONInt},
+	}}}}
+	for _, legacy := range []bool{false, true} {
+		out, err := NewAdapterGenerator(Config{Hash: true, LegacyCPP: legacy}, "").generateTypes(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		specializations := []string{"#include <functional>", "namespace std {"}
+		if legacy {
+			checkOutput(t, out, []string{"hash_value(const Root& obj)"}, specializations)
+		} else {
+			checkOutput(t, out, specializations, nil)
+		}
+	}
+}
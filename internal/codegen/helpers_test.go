@@ -0,0 +1,25 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+// checkOutput reports the snippets of want missing from the generated code
+// and the snippets of notWant found in it
+func checkOutput(t *testing.T, out string, want, notWant []string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			t.Errorf("output lacks %q", w)
+		}
+	}
+	for _, w := range notWant {
+		if strings.Contains(out, w) {
+			t.Errorf("output contains %q", w)
+		}
+	}
+	if t.Failed() {
+		t.Logf("output:\n%s", out)
+	}
+}
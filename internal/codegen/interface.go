@@ -15,10 +15,25 @@ const (
 type Config struct {
 	Parser       ParserType
 	LegacyCPP    bool
+	CppStandard  int // 11 (default), 17 or 20; ignored when LegacyCPP is set
 	Namespace    string
 	CamelCase    bool
 	OptionalNull bool
-	StringRef    bool
+	Nullable     bool // tri-state Nullable<T> for fields seen as null
+	Equality     bool // operator== and operator!= for structs
+	Ordering     bool // operator< too; implies Equality
+	Hash         bool // std::hash specializations and hash_value for structs
+	// SampleDefaults initializes scalar members with their first sample value
+	SampleDefaults bool
+	// Encapsulate generates classes with private members and accessors named
+	// by GetterPattern and SetterPattern ({name} or {Name} is the member)
+	Encapsulate   bool
+	GetterPattern string
+	SetterPattern string
+	Builders      bool // builders.h with a fluent <Name>Builder per struct
+	StringRef     bool
+	Provenance    bool // comment members with their sample files, pointers and values
+	DocComments   bool // Doxygen comments on structs and members: JSON key, pointer, type, example
 }
 
 // CodeGenerator is the interface that all parser-specific generators must implement
@@ -0,0 +1,147 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"json2cpp/internal/types"
+)
+
+// nullableDefinition is emitted into types.h when a member is declared as
+// Nullable<T>. It only needs the standard library and works in C++03.
+const nullableDefinition = `// Tri-state value of a field seen as null: absent (undefined), null, or a
+// value. JSON Merge Patch gives the states different meanings: absent leaves
+// the target alone and null clears it. T must be default constructible.
+template <typename T>
+class Nullable {
+public:
+    enum State { Undefined, Null, Value };
+
+    Nullable() : state_(Undefined), value_() {}
+    Nullable(const T& value) : state_(Value), value_(value) {}
+    Nullable& operator=(const T& value) {
+        state_ = Value;
+        value_ = value;
+        return *this;
+    }
+
+    State state() const { return state_; }
+    bool is_undefined() const { return state_ == Undefined; }
+    bool is_null() const { return state_ == Null; }
+    bool has_value() const { return state_ == Value; }
+    T& value() { return value_; }
+    const T& value() const { return value_; }
+    T& operator*() { return value_; }
+    const T& operator*() const { return value_; }
+    T* operator->() { return &value_; }
+    const T* operator->() const { return &value_; }
+
+    // Makes the nullable hold a default constructed value and returns it.
+    T& emplace() {
+        state_ = Value;
+        value_ = T();
+        return value_;
+    }
+    void set_null() {
+        state_ = Null;
+        value_ = T();
+    }
+    void reset() {
+        state_ = Undefined;
+        value_ = T();
+    }
+
+private:
+    State state_;
+    T value_;
+};
+`
+
+// isNullableMember reports whether a field is declared as Nullable<T>
+func (g *AdapterGenerator) isNullableMember(f *types.Field) bool {
+	return g.nullable && f.Nullable
+}
+
+// usesNullable reports whether any member is declared as Nullable<T>
+func (g *AdapterGenerator) usesNullable(info *types.TypeInfo) bool {
+	for _, s := range info.Structs {
+		for _, f := range s.Fields {
+			if g.isNullableMember(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// memberCheck returns the condition testing that json has a member
+func (g *AdapterGenerator) memberCheck(jsonName string) string {
+	switch g.parser {
+	case ParserNlohmann:
+		return fmt.Sprintf("json.contains(\"%s\")", jsonName)
+	case ParserJsonCpp:
+		return fmt.Sprintf("json.isMember(\"%s\")", jsonName)
+	default:
+		return fmt.Sprintf("json.HasMember(\"%s\")", jsonName)
+	}
+}
+
+// nullCheck returns the condition testing that a member of json is null
+func (g *AdapterGenerator) nullCheck(jsonName string) string {
+	switch g.parser {
+	case ParserNlohmann:
+		return fmt.Sprintf("json[\"%s\"].is_null()", jsonName)
+	case ParserJsonCpp:
+		return fmt.Sprintf("json[\"%s\"].isNull()", jsonName)
+	default:
+		return fmt.Sprintf("json[\"%s\"].IsNull()", jsonName)
+	}
+}
+
+// writeNull returns the statement writing null for a member of json
+func (g *AdapterGenerator) writeNull(jsonName string) string {
+	switch g.parser {
+	case ParserNlohmann:
+		return fmt.Sprintf("json[\"%s\"] = nullptr;", jsonName)
+	case ParserJsonCpp:
+		return fmt.Sprintf("json[\"%s\"] = Json::Value(Json::nullValue);", jsonName)
+	default:
+		return fmt.Sprintf("json.AddMember(\"%s\", rapidjson::Value(rapidjson::kNullType), allocator);", jsonName)
+	}
+}
+
+// wrapNullableDeserialize keeps the three states of a Nullable member apart:
+// an absent member leaves it undefined, null makes it null, and anything else
+// is read as its value. A value of the wrong JSON type, or any value of a
+// field only ever seen as null, leaves it undefined.
+func (g *AdapterGenerator) wrapNullableDeserialize(f *types.Field, code string) string {
+	member := "obj." + g.memberName(f)
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("    if (!%s) {\n", g.memberCheck(f.JSONName)))
+	buf.WriteString(fmt.Sprintf("        %s.reset();\n", member))
+	buf.WriteString(fmt.Sprintf("    } else if (%s) {\n", g.nullCheck(f.JSONName)))
+	buf.WriteString(fmt.Sprintf("        %s.set_null();\n", member))
+	buf.WriteString("    } else {\n")
+	if f.Type == types.JSONNull {
+		buf.WriteString(fmt.Sprintf("        %s.reset();\n", member))
+	} else {
+		buf.WriteString(fmt.Sprintf("        %s.reset();\n", member))
+		buf.WriteString(indentCode(code))
+	}
+	buf.WriteString("    }\n")
+	return buf.String()
+}
+
+// wrapNullableSerialize writes null for a null Nullable member, its value
+// when it has one, and leaves the member out when it is undefined.
+func (g *AdapterGenerator) wrapNullableSerialize(f *types.Field, code string) string {
+	member := "obj." + g.memberName(f)
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("    if (%s.is_null()) {\n", member))
+	buf.WriteString(fmt.Sprintf("        %s\n", g.writeNull(f.JSONName)))
+	if f.Type != types.JSONNull {
+		buf.WriteString(fmt.Sprintf("    } else if (%s.has_value()) {\n", member))
+		buf.WriteString(indentCode(code))
+	}
+	buf.WriteString("    }\n")
+	return buf.String()
+}
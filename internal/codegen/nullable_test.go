@@ -0,0 +1,149 @@
+package codegen
+
+import (
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestWrapNullableDeserialize(t *testing.T) {
+	tests := []struct {
+		name   string
+		parser ParserType
+		kind   types.JSONType
+		want   string
+	}{
+		{"rapidjson", ParserRapidJSON, types.JSONString,
+			"    if (!json.HasMember(\"note\")) {\n" +
+				"        obj.note.reset();\n" +
+				"    } else if (json[\"note\"].IsNull()) {\n" +
+				"        obj.note.set_null();\n" +
+				"    } else {\n" +
+				"        obj.note.reset();\n" +
+				"        read(note);\n" +
+				"    }\n"},
+		{"nlohmann", ParserNlohmann, types.JSONString,
+			"    if (!json.contains(\"note\")) {\n" +
+				"        obj.note.reset();\n" +
+				"    } else if (json[\"note\"].is_null()) {\n"},
+		{"jsoncpp", ParserJsonCpp, types.JSONString,
+			"    if (!json.isMember(\"note\")) {\n" +
+				"        obj.note.reset();\n" +
+				"    } else if (json[\"note\"].isNull()) {\n"},
+		// Only ever null: there is no value to read
+		{"only null", ParserRapidJSON, types.JSONNull,
+			"        obj.note.set_null();\n" +
+				"    } else {\n" +
+				"        obj.note.reset();\n" +
+				"    }\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser, Nullable: true}, "")
+			f := &types.Field{Name: "note", JSONName: "note", Type: tt.kind, Nullable: true}
+			var notWant []string
+			if tt.kind == types.JSONNull {
+				notWant = []string{"read(note)", "emplace()"}
+			}
+			checkOutput(t, g.wrapNullableDeserialize(f, "    read(note);\n"), []string{tt.want}, notWant)
+		})
+	}
+}
+
+func TestWrapNullableSerialize(t *testing.T) {
+	tests := []struct {
+		parser ParserType
+		kind   types.JSONType
+		want   string
+	}{
+		{ParserRapidJSON, types.JSONString,
+			"    if (obj.note.is_null()) {\n" +
+				"        json.AddMember(\"note\", rapidjson::Value(rapidjson::kNullType), allocator);\n" +
+				"    } else if (obj.note.has_value()) {\n" +
+				"        write(note);\n" +
+				"    }\n"},
+		{ParserNlohmann, types.JSONString,
+			"    if (obj.note.is_null()) {\n" +
+				"        json[\"note\"] = nullptr;\n" +
+				"    } else if (obj.note.has_value()) {\n" +
+				"        write(note);\n" +
+				"    }\n"},
+		{ParserJsonCpp, types.JSONNull,
+			"    if (obj.note.is_null()) {\n" +
+				"        json[\"note\"] = Json::Value(Json::nullValue);\n" +
+				"    }\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.parser)+"/"+tt.kind.String(), func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser, Nullable: true}, "")
+			f := &types.Field{Name: "note", JSONName: "note", Type: tt.kind, Nullable: true}
+			if got := g.wrapNullableSerialize(f, "    write(note);\n"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// A field that is both optional and nullable is declared Nullable<T>, which
+// also covers absence
+func TestNullableTakesPrecedenceOverOptional(t *testing.T) {
+	f := &types.Field{Name: "note", JSONName: "note", Type: types.JSONString, IsOptional: true, Nullable: true}
+	tests := []struct {
+		cfg          Config
+		wantOptional bool
+		wantNullable bool
+	}{
+		{Config{OptionalNull: true}, true, false},
+		{Config{Nullable: true}, false, true},
+		{Config{OptionalNull: true, Nullable: true}, false, true},
+	}
+	for _, tt := range tests {
+		g := NewAdapterGenerator(tt.cfg, "")
+		if got := g.isOptionalMember(f); got != tt.wantOptional {
+			t.Errorf("%+v: isOptionalMember = %v, want %v", tt.cfg, got, tt.wantOptional)
+		}
+		if got := g.isNullableMember(f); got != tt.wantNullable {
+			t.Errorf("%+v: isNullableMember = %v, want %v", tt.cfg, got, tt.wantNullable)
+		}
+	}
+	g := NewAdapterGenerator(Config{Nullable: true}, "")
+	if got := g.memberRef(f); got != "(*obj.note)" {
+		t.Errorf("memberRef = %s, want (*obj.note)", got)
+	}
+}
+
+func TestNullableDefinitionOnlyWhenUsed(t *testing.T) {
+	for _, nullable := range []bool{false, true} {
+		info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: []*types.Field{
+			{Name: "note", JSONName: "note", Type: types.JSONString, Nullable: true},
+		}}}}
+		out, err := NewAdapterGenerator(Config{Nullable: nullable}, "").generateTypes(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nullable {
+			checkOutput(t, out, []string{nullableDefinition, "    Nullable<std::string> note;\n"}, nil)
+		} else {
+			checkOutput(t, out, []string{"    std::string note;\n"}, []string{nullableDefinition})
+		}
+	}
+}
+
+func TestNullableEngageOnlyWhenUsed(t *testing.T) {
+	for _, nullable := range []bool{false, true} {
+		info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: []*types.Field{
+			{Name: "note", JSONName: "note", Type: types.JSONString, Nullable: true},
+		}}}}
+		out, err := NewAdapterGenerator(Config{Parser: ParserNlohmann, Nullable: nullable}, "").generateSerializerImpl(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nullable {
+			checkOutput(t, out, []string{nullableEngageHelper, "Engage(obj.note) = json[\"note\"]"}, []string{engageHelper})
+		} else {
+			checkOutput(t, out, nil, []string{"Engage"})
+		}
+	}
+}
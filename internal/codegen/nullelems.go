@@ -0,0 +1,110 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+
+	"json2cpp/internal/types"
+)
+
+// hasNullableElems reports whether a field is an array of scalars that also
+// held null elements, declared as std::vector<Optional<T>> so that the nulls
+// keep their positions
+func hasNullableElems(f *types.Field) bool {
+	return f.Type == types.JSONArray && f.ElemNullable && f.NestedType == nil &&
+		!isVariant(f) && f.Format == types.FormatDefault
+}
+
+// nullableElemsCppType returns the vector of Optional elements of a field
+func (g *AdapterGenerator) nullableElemsCppType(f *types.Field) string {
+	elem := fmt.Sprintf("Optional<%s>", variantKindType(f.ElemType))
+	if g.legacyCpp {
+		return fmt.Sprintf("std::vector<%s >", elem)
+	}
+	return fmt.Sprintf("std::vector<%s>", elem)
+}
+
+// generateDeserializeNullableElems generates deserialization code for an
+// array with null elements: a null, or an element of another kind, is read
+// as an empty Optional
+func (g *AdapterGenerator) generateDeserializeNullableElems(f *types.Field) (string, error) {
+	var buf bytes.Buffer
+	member := g.targetRef(f)
+	jsonName := f.JSONName
+	elemType := variantKindType(f.ElemType)
+
+	switch g.parser {
+	case ParserRapidJSON:
+		buf.WriteString(fmt.Sprintf("    if (json.HasMember(\"%s\") && json[\"%s\"].IsArray()) {\n", jsonName, jsonName))
+		buf.WriteString(fmt.Sprintf("        const rapidjson::Value& arr = json[\"%s\"];\n", jsonName))
+		buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
+		buf.WriteString("        for (rapidjson::SizeType i = 0; i < arr.Size(); ++i) {\n")
+		buf.WriteString("            const rapidjson::Value& value = arr[i];\n")
+	case ParserNlohmann:
+		buf.WriteString(fmt.Sprintf("    if (json.contains(\"%s\") && json[\"%s\"].is_array()) {\n", jsonName, jsonName))
+		buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
+		buf.WriteString(fmt.Sprintf("        for (const auto& value : json[\"%s\"]) {\n", jsonName))
+	case ParserJsonCpp:
+		buf.WriteString(fmt.Sprintf("    if (json.isMember(\"%s\") && json[\"%s\"].isArray()) {\n", jsonName, jsonName))
+		buf.WriteString(fmt.Sprintf("        const Json::Value& arr = json[\"%s\"];\n", jsonName))
+		buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
+		buf.WriteString("        for (Json::ArrayIndex i = 0; i < arr.size(); ++i) {\n")
+		buf.WriteString("            const Json::Value& value = arr[i];\n")
+	default:
+		return "", fmt.Errorf("unsupported parser: %v", g.parser)
+	}
+	check, read := g.variantRead(f.ElemType, "value")
+	buf.WriteString(fmt.Sprintf("            Optional<%s> item;\n", elemType))
+	buf.WriteString(fmt.Sprintf("            if (%s) {\n", check))
+	buf.WriteString(fmt.Sprintf("                item = %s;\n", read))
+	buf.WriteString("            }\n")
+	buf.WriteString(fmt.Sprintf("            %s.push_back(item);\n", member))
+	buf.WriteString("        }\n")
+	buf.WriteString("    }\n")
+	return buf.String(), nil
+}
+
+// generateSerializeNullableElems generates serialization code for an array
+// with null elements, writing an empty Optional as null
+func (g *AdapterGenerator) generateSerializeNullableElems(f *types.Field) (string, error) {
+	var buf bytes.Buffer
+	member := g.memberRef(f)
+	jsonName := f.JSONName
+	value := g.variantWrite(f.ElemType, "item")
+
+	switch g.parser {
+	case ParserRapidJSON:
+		buf.WriteString("    {\n")
+		buf.WriteString("        rapidjson::Value arr(rapidjson::kArrayType);\n")
+		buf.WriteString(fmt.Sprintf("        for (const auto& item : %s) {\n", member))
+		buf.WriteString("            if (item.has_value()) {\n")
+		buf.WriteString(fmt.Sprintf("                arr.PushBack(%s, allocator);\n", value))
+		buf.WriteString("            } else {\n")
+		buf.WriteString("                arr.PushBack(rapidjson::Value(), allocator);\n")
+		buf.WriteString("            }\n")
+		buf.WriteString("        }\n")
+		buf.WriteString(fmt.Sprintf("        json.AddMember(\"%s\", arr, allocator);\n", jsonName))
+		buf.WriteString("    }\n")
+	case ParserNlohmann:
+		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = nlohmann::json::array();\n", jsonName))
+		buf.WriteString(fmt.Sprintf("    for (const auto& item : %s) {\n", member))
+		buf.WriteString("        if (item.has_value()) {\n")
+		buf.WriteString(fmt.Sprintf("            json[\"%s\"].push_back(%s);\n", jsonName, value))
+		buf.WriteString("        } else {\n")
+		buf.WriteString(fmt.Sprintf("            json[\"%s\"].push_back(nullptr);\n", jsonName))
+		buf.WriteString("        }\n")
+		buf.WriteString("    }\n")
+	case ParserJsonCpp:
+		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = Json::Value(Json::arrayValue);\n", jsonName))
+		buf.WriteString(fmt.Sprintf("    for (const auto& item : %s) {\n", member))
+		buf.WriteString("        if (item.has_value()) {\n")
+		buf.WriteString(fmt.Sprintf("            json[\"%s\"].append(%s);\n", jsonName, value))
+		buf.WriteString("        } else {\n")
+		buf.WriteString(fmt.Sprintf("            json[\"%s\"].append(Json::Value());\n", jsonName))
+		buf.WriteString("        }\n")
+		buf.WriteString("    }\n")
+	default:
+		return "", fmt.Errorf("unsupported parser: %v", g.parser)
+	}
+	return buf.String(), nil
+}
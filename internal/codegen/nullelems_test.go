@@ -0,0 +1,119 @@
+package codegen
+
+import (
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestHasNullableElems(t *testing.T) {
+	tests := []struct {
+		name  string
+		field types.Field
+		want  bool
+	}{
+		{"scalars with nulls", types.Field{Type: types.JSONArray, ElemType: types.JSONInt, ElemNullable: true}, true},
+		{"scalars without nulls", types.Field{Type: types.JSONArray, ElemType: types.JSONInt}, false},
+		{"objects with nulls", types.Field{Type: types.JSONArray, ElemType: types.JSONObject, ElemNullable: true,
+			NestedType: &types.Struct{Name: "Item"}}, false},
+		{"variant elements", types.Field{Type: types.JSONArray, ElemType: types.JSONVariant, ElemNullable: true,
+			Variants: []types.JSONType{types.JSONInt, types.JSONString}}, false},
+		{"formatted elements", types.Field{Type: types.JSONArray, ElemType: types.JSONString, ElemNullable: true,
+			Format: types.FormatBase64}, false},
+		{"nullable scalar", types.Field{Type: types.JSONInt, Nullable: true}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := tt.field
+			if got := hasNullableElems(&field); got != tt.want {
+				t.Errorf("hasNullableElems = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNullableElemsCppType(t *testing.T) {
+	tests := []struct {
+		legacy   bool
+		elemType types.JSONType
+		want     string
+	}{
+		{false, types.JSONInt, "std::vector<Optional<int64_t>>"},
+		{false, types.JSONString, "std::vector<Optional<std::string>>"},
+		{true, types.JSONFloat, "std::vector<Optional<double> >"},
+	}
+	for _, tt := range tests {
+		f := &types.Field{Type: types.JSONArray, ElemType: tt.elemType, ElemNullable: true}
+		got, err := NewAdapterGenerator(Config{LegacyCPP: tt.legacy}, "").getCppType(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("getCppType(legacy=%v) = %q, want %q", tt.legacy, got, tt.want)
+		}
+	}
+}
+
+// A null element, or one of another kind, is read as an empty Optional and
+// written back as null, keeping the positions of the others
+func TestNullableElemsReadWrite(t *testing.T) {
+	f := &types.Field{Name: "scores", JSONName: "scores", Type: types.JSONArray, ElemType: types.JSONInt, ElemNullable: true}
+	tests := []struct {
+		parser ParserType
+		read   []string
+		write  []string
+	}{
+		{ParserRapidJSON,
+			[]string{"    if (json.HasMember(\"scores\") && json[\"scores\"].IsArray()) {\n",
+				"        obj.scores.clear();\n",
+				"            Optional<int64_t> item;\n" +
+					"            if (value.IsInt64()) {\n" +
+					"                item = value.GetInt64();\n" +
+					"            }\n" +
+					"            obj.scores.push_back(item);\n"},
+			[]string{"            if (item.has_value()) {\n" +
+				"                arr.PushBack(*item, allocator);\n" +
+				"            } else {\n" +
+				"                arr.PushBack(rapidjson::Value(), allocator);\n" +
+				"            }\n",
+				"        json.AddMember(\"scores\", arr, allocator);\n"}},
+		{ParserNlohmann,
+			[]string{"        for (const auto& value : json[\"scores\"]) {\n",
+				"            if (value.is_number_integer()) {\n                item = value.get<int64_t>();\n"},
+			[]string{"    json[\"scores\"] = nlohmann::json::array();\n",
+				"            json[\"scores\"].push_back(*item);\n        } else {\n            json[\"scores\"].push_back(nullptr);\n"}},
+		{ParserJsonCpp,
+			[]string{"        for (Json::ArrayIndex i = 0; i < arr.size(); ++i) {\n",
+				"            if (value.isInt64()) {\n                item = value.asInt64();\n"},
+			[]string{"            json[\"scores\"].append(static_cast<Json::Int64>(*item));\n" +
+				"        } else {\n" +
+				"            json[\"scores\"].append(Json::Value());\n"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.parser), func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser}, "")
+			read, err := g.generateDeserializeValue(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, read, tt.read, nil)
+			write, err := g.generateSerializeValue(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, write, tt.write, nil)
+		})
+	}
+}
+
+// Optional is defined for its elements even without --optional-null
+func TestNullableElemsDefineOptional(t *testing.T) {
+	info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: []*types.Field{
+		{Name: "scores", JSONName: "scores", Type: types.JSONArray, ElemType: types.JSONString, ElemNullable: true},
+	}}}}
+	out, err := NewAdapterGenerator(Config{}, "").generateTypes(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkOutput(t, out, []string{"class Optional {", "    std::vector<Optional<std::string>> scores;\n"}, nil)
+}
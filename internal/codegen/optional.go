@@ -0,0 +1,173 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"json2cpp/internal/types"
+	"strings"
+)
+
+// optionalAlias is emitted into types.h for C++17 and later, where the
+// standard type does the job.
+const optionalAlias = `// Optional value of an optional field: absent or null in JSON when empty.
+template <typename T>
+using Optional = std::optional<T>;
+`
+
+// optionalDefinition is emitted into types.h before C++17. It offers the
+// subset of the std::optional interface used by the serializers, so user
+// code keeps compiling when the target standard is raised.
+const optionalDefinition = `// Optional value of an optional field: absent or null in JSON when empty.
+// A minimal std::optional for C++ before 17; T must be default constructible.
+template <typename T>
+class Optional {
+public:
+    Optional() : has_(false), value_() {}
+    Optional(const T& value) : has_(true), value_(value) {}
+    Optional& operator=(const T& value) {
+        has_ = true;
+        value_ = value;
+        return *this;
+    }
+
+    bool has_value() const { return has_; }
+    T& value() { return value_; }
+    const T& value() const { return value_; }
+    T& operator*() { return value_; }
+    const T& operator*() const { return value_; }
+    T* operator->() { return &value_; }
+    const T* operator->() const { return &value_; }
+
+    // Makes the optional hold a default constructed value and returns it.
+    T& emplace() {
+        has_ = true;
+        value_ = T();
+        return value_;
+    }
+    void reset() {
+        has_ = false;
+        value_ = T();
+    }
+
+private:
+    bool has_;
+    T value_;
+};
+`
+
+// engageHelper is emitted into the serializer implementation when a member is
+// declared as Optional<T>; nullableEngageHelper when one is Nullable<T>.
+// Deserialization stores into Engage(member), so a member only holds a value
+// once one of the expected JSON type was read.
+const engageHelper = `// Engage returns the value of member, default constructing it when empty
+template <typename T>
+static T& Engage(Optional<T>& member) {
+    if (!member.has_value()) {
+        member.emplace();
+    }
+    return *member;
+}
+`
+
+const nullableEngageHelper = `// Engage returns the value of member, default constructing it when it has none
+template <typename T>
+static T& Engage(Nullable<T>& member) {
+    if (!member.has_value()) {
+        member.emplace();
+    }
+    return *member;
+}
+`
+
+// isOptionalMember reports whether a field is declared as Optional<T>; a
+// Nullable<T> member covers absence too
+func (g *AdapterGenerator) isOptionalMember(f *types.Field) bool {
+	return g.optionalNull && f.IsOptional && !g.isNullableMember(f)
+}
+
+// usesOptional reports whether any member is declared as Optional<T>, or
+// holds Optional<T> elements
+func (g *AdapterGenerator) usesOptional(info *types.TypeInfo) bool {
+	for _, s := range info.Structs {
+		for _, f := range s.Fields {
+			if g.isOptionalMember(f) || hasNullableElems(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// memberRef returns the expression for the value of a field in obj: the
+// member itself, or the value held by its Optional or Nullable
+func (g *AdapterGenerator) memberRef(f *types.Field) string {
+	member := "obj." + g.memberName(f)
+	if g.isOptionalMember(f) || g.isNullableMember(f) {
+		return "(*" + member + ")"
+	}
+	return member
+}
+
+// targetRef returns the expression deserialization code stores the value of
+// a field into: the member itself, or the value of its Optional or Nullable,
+// which Engage makes hold a value on the first store
+func (g *AdapterGenerator) targetRef(f *types.Field) string {
+	member := "obj." + g.memberName(f)
+	if g.isOptionalMember(f) || g.isNullableMember(f) {
+		return "Engage(" + member + ")"
+	}
+	return member
+}
+
+// presentCheck returns the condition testing that json has a non-null member
+func (g *AdapterGenerator) presentCheck(jsonName string) string {
+	return fmt.Sprintf("%s && !%s", g.memberCheck(jsonName), g.nullCheck(jsonName))
+}
+
+// wrapOptionalDeserialize empties an Optional member and runs its
+// deserialization code when the member is present and not null. The code
+// engages the Optional only when it reads a value, so one of the wrong JSON
+// type leaves it empty. A field only ever seen as null has no value type to
+// read, so it always ends up empty.
+func (g *AdapterGenerator) wrapOptionalDeserialize(f *types.Field, code string) string {
+	member := "obj." + g.memberName(f)
+	if f.Type == types.JSONNull {
+		return fmt.Sprintf("    %s.reset();\n", member)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("    %s.reset();\n", member))
+	buf.WriteString(fmt.Sprintf("    if (%s) {\n", g.presentCheck(f.JSONName)))
+	buf.WriteString(indentCode(code))
+	buf.WriteString("    }\n")
+	return buf.String()
+}
+
+// wrapOptionalSerialize makes the serialization code of an Optional member
+// run only when it holds a value. A field only ever seen as null is written
+// as null when set.
+func (g *AdapterGenerator) wrapOptionalSerialize(f *types.Field, code string) string {
+	member := "obj." + g.memberName(f)
+	if f.Type == types.JSONNull {
+		code = "    " + g.writeNull(f.JSONName) + "\n"
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("    if (%s.has_value()) {\n", member))
+	buf.WriteString(indentCode(code))
+	buf.WriteString("    }\n")
+	return buf.String()
+}
+
+// indentCode indents every non-empty line of generated code by one level
+func indentCode(code string) string {
+	lines := strings.SplitAfter(code, "\n")
+	var buf bytes.Buffer
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			buf.WriteString("    ")
+		}
+		buf.WriteString(line)
+	}
+	return buf.String()
+}
@@ -0,0 +1,178 @@
+package codegen
+
+import (
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestWrapOptionalDeserialize(t *testing.T) {
+	name := &types.Field{Name: "name", JSONName: "name", Type: types.JSONString, IsOptional: true}
+	null := &types.Field{Name: "gone", JSONName: "gone", Type: types.JSONNull, IsOptional: true}
+	code := "    read(name);\n"
+
+	tests := []struct {
+		name    string
+		parser  ParserType
+		field   *types.Field
+		want    string
+		notWant []string
+	}{
+		{"rapidjson", ParserRapidJSON, name,
+			"    obj.name.reset();\n" +
+				"    if (json.HasMember(\"name\") && !json[\"name\"].IsNull()) {\n" +
+				"        read(name);\n" +
+				"    }\n", []string{"emplace"}},
+		{"nlohmann", ParserNlohmann, name,
+			"    if (json.contains(\"name\") && !json[\"name\"].is_null()) {\n", nil},
+		{"jsoncpp", ParserJsonCpp, name,
+			"    if (json.isMember(\"name\") && !json[\"name\"].isNull()) {\n", nil},
+		{"only null", ParserRapidJSON, null, "    obj.gone.reset();\n", []string{"if (", "read(name)"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser, OptionalNull: true}, "")
+			checkOutput(t, g.wrapOptionalDeserialize(tt.field, code), []string{tt.want}, tt.notWant)
+		})
+	}
+}
+
+func TestWrapOptionalSerialize(t *testing.T) {
+	tests := []struct {
+		parser ParserType
+		field  types.JSONType
+		want   string
+	}{
+		{ParserRapidJSON, types.JSONString,
+			"    if (obj.x.has_value()) {\n        write(x);\n    }\n"},
+		{ParserRapidJSON, types.JSONNull,
+			"    if (obj.x.has_value()) {\n" +
+				"        json.AddMember(\"x\", rapidjson::Value(rapidjson::kNullType), allocator);\n    }\n"},
+		{ParserNlohmann, types.JSONNull,
+			"    if (obj.x.has_value()) {\n        json[\"x\"] = nullptr;\n    }\n"},
+		{ParserJsonCpp, types.JSONNull,
+			"    if (obj.x.has_value()) {\n        json[\"x\"] = Json::Value(Json::nullValue);\n    }\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.parser)+"/"+tt.field.String(), func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser, OptionalNull: true}, "")
+			f := &types.Field{Name: "x", JSONName: "x", Type: tt.field, IsOptional: true}
+			if got := g.wrapOptionalSerialize(f, "    write(x);\n"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOptionalMembers(t *testing.T) {
+	fields := func() []*types.Field {
+		return []*types.Field{
+			{Name: "id", JSONName: "id", Type: types.JSONInt},
+			{Name: "name", JSONName: "name", Type: types.JSONString, IsOptional: true},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "without --optional-null",
+			cfg:     Config{},
+			want:    []string{"    std::string name;\n"},
+			notWant: []string{"Optional"},
+		},
+		{
+			name: "C++11 bundled Optional",
+			cfg:  Config{OptionalNull: true},
+			want: []string{optionalDefinition, "    Optional<std::string> name;\n", "    int64_t id"},
+			notWant: []string{
+				"#include <optional>", optionalAlias,
+			},
+		},
+		{
+			name:    "C++17 std::optional",
+			cfg:     Config{OptionalNull: true, CppStandard: 17},
+			want:    []string{"#include <optional>\n", optionalAlias, "    Optional<std::string> name;\n"},
+			notWant: []string{optionalDefinition},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: fields()}}}
+			out, err := NewAdapterGenerator(tt.cfg, "").generateTypes(info)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkOutput(t, out, tt.want, tt.notWant)
+		})
+	}
+}
+
+func TestMemberRef(t *testing.T) {
+	g := NewAdapterGenerator(Config{OptionalNull: true}, "")
+	tests := []struct {
+		field *types.Field
+		want  string
+	}{
+		{&types.Field{Name: "id", Type: types.JSONInt}, "obj.id"},
+		{&types.Field{Name: "name", Type: types.JSONString, IsOptional: true}, "(*obj.name)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field.Name, func(t *testing.T) {
+			if got := g.memberRef(tt.field); got != tt.want {
+				t.Errorf("memberRef = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Reads store through Engage, so a value of the wrong JSON type leaves the
+// Optional empty
+func TestTargetRef(t *testing.T) {
+	g := NewAdapterGenerator(Config{OptionalNull: true, Nullable: true}, "")
+	tests := []struct {
+		field *types.Field
+		want  string
+	}{
+		{&types.Field{Name: "id", Type: types.JSONInt}, "obj.id"},
+		{&types.Field{Name: "name", Type: types.JSONString, IsOptional: true}, "Engage(obj.name)"},
+		{&types.Field{Name: "note", Type: types.JSONString, IsOptional: true, Nullable: true}, "Engage(obj.note)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.field.Name, func(t *testing.T) {
+			if got := g.targetRef(tt.field); got != tt.want {
+				t.Errorf("targetRef = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEngageOnlyWhenUsed(t *testing.T) {
+	for _, optionalNull := range []bool{false, true} {
+		info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: []*types.Field{
+			{Name: "age", JSONName: "age", Type: types.JSONInt, IsOptional: true},
+		}}}}
+		out, err := NewAdapterGenerator(Config{OptionalNull: optionalNull}, "").generateSerializerImpl(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if optionalNull {
+			checkOutput(t, out, []string{engageHelper, "        Engage(obj.age) = json[\"age\"].GetInt64();\n"}, nil)
+		} else {
+			checkOutput(t, out, nil, []string{"Engage"})
+		}
+	}
+}
+
+func TestIndentCode(t *testing.T) {
+	got := indentCode("    a;\n\n    b;\n")
+	if want := "        a;\n\n        b;\n"; got != want {
+		t.Errorf("indentCode = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,52 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"json2cpp/internal/types"
+)
+
+const (
+	maxProvenanceSources = 5  // files listed before "+N more"
+	maxExampleLength     = 40 // characters of an example value
+)
+
+// provenanceComment renders the sample files, JSON Pointers and example
+// values of a field as comment lines placed above its member.
+func provenanceComment(f *types.Field) string {
+	var b strings.Builder
+	if len(f.Sources) > 0 {
+		sources := f.Sources
+		more := ""
+		if len(sources) > maxProvenanceSources {
+			more = fmt.Sprintf(" (+%d more)", len(sources)-maxProvenanceSources)
+			sources = sources[:maxProvenanceSources]
+		}
+		b.WriteString(fmt.Sprintf("    // from %s%s", commentText(strings.Join(sources, ", ")), more))
+	} else {
+		b.WriteString("    // from sample")
+	}
+	// Brackets keep a pointer ending in '\' from splicing the next line
+	b.WriteString(fmt.Sprintf("  [%s]\n", commentText(strings.Join(f.Pointers, ", "))))
+
+	if len(f.Examples) > 0 {
+		examples := make([]string, 0, len(f.Examples))
+		for _, e := range f.Examples {
+			examples = append(examples, truncateExample(e))
+		}
+		b.WriteString(fmt.Sprintf("    // e.g. %s\n", strings.Join(examples, ", ")))
+	}
+	return b.String()
+}
+
+// truncateExample shortens long example values and escapes their control
+// characters. A cut value ends in "..." rather than in a backslash that would
+// continue the comment.
+func truncateExample(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxExampleLength {
+		return commentText(text)
+	}
+	return commentText(string(runes[:maxExampleLength])) + "..."
+}
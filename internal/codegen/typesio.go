@@ -0,0 +1,218 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"json2cpp/internal/types"
+)
+
+// printHelpers are the PrintValue overloads for the scalar members, emitted
+// into types_io.h. Strings are quoted and escaped as in JSON.
+const printHelpers = `inline void PrintValue(std::ostream& os, bool value) {
+    os << (value ? "true" : "false");
+}
+
+inline void PrintValue(std::ostream& os, int64_t value) {
+    os << value;
+}
+
+inline void PrintValue(std::ostream& os, int8_t value) {
+    os << static_cast<int>(value);
+}
+
+inline void PrintValue(std::ostream& os, uint8_t value) {
+    os << static_cast<int>(value);
+}
+
+inline void PrintValue(std::ostream& os, double value) {
+    os << value;
+}
+
+inline void PrintValue(std::ostream& os, const std::string& value) {
+    static const char hex[] = "0123456789abcdef";
+    os << '"';
+    for (size_t i = 0; i < value.size(); ++i) {
+        unsigned char c = static_cast<unsigned char>(value[i]);
+        switch (c) {
+        case '"': os << "\\\""; break;
+        case '\\': os << "\\\\"; break;
+        case '\n': os << "\\n"; break;
+        case '\r': os << "\\r"; break;
+        case '\t': os << "\\t"; break;
+        default:
+            if (c < 0x20) {
+                os << "\\u00" << hex[c >> 4] << hex[c & 0xf];
+            } else {
+                os << value[i];
+            }
+        }
+    }
+    os << '"';
+}
+`
+
+// printDecimal prints the exact decimal text.
+const printDecimal = `inline void PrintValue(std::ostream& os, const Decimal& value) {
+    os << value.ToString();
+}
+`
+
+// printGeneric covers generated structs and user types named by hints, which
+// provide operator<<.
+var printGeneric = cppTemplate{"template <typename T>\nvoid PrintValue(std::ostream& os, const T& value)", `
+template <typename T>
+void PrintValue(std::ostream& os, const T& value) {
+    os << value;
+}
+`}
+
+var printVector = cppTemplate{"template <typename T>\nvoid PrintValue(std::ostream& os, const std::vector<T>& value)", `
+template <typename T>
+void PrintValue(std::ostream& os, const std::vector<T>& value) {
+    os << '[';
+    for (size_t i = 0; i < value.size(); ++i) {
+        if (i > 0) {
+            os << ", ";
+        }
+        PrintValue(os, value[i]);
+    }
+    os << ']';
+}
+`}
+
+var printOptional = cppTemplate{"template <typename T>\nvoid PrintValue(std::ostream& os, const Optional<T>& value)", `
+template <typename T>
+void PrintValue(std::ostream& os, const Optional<T>& value) {
+    if (value.has_value()) {
+        PrintValue(os, *value);
+    } else {
+        os << "null";
+    }
+}
+`}
+
+var printNullable = cppTemplate{"template <typename T>\nvoid PrintValue(std::ostream& os, const Nullable<T>& value)", `
+template <typename T>
+void PrintValue(std::ostream& os, const Nullable<T>& value) {
+    if (value.has_value()) {
+        PrintValue(os, *value);
+    } else if (value.is_null()) {
+        os << "null";
+    } else {
+        os << "undefined";
+    }
+}
+`}
+
+var printVariant = cppTemplate{"template <typename... Ts>\nvoid PrintValue(std::ostream& os, const std::variant<Ts...>& value)", `
+template <typename... Ts>
+void PrintValue(std::ostream& os, const std::variant<Ts...>& value) {
+    std::visit([&os](const auto& alternative) { PrintValue(os, alternative); }, value);
+}
+`}
+
+// generateTypesIO generates types_io.h: an operator<< per struct printing
+// Name{member=value, ...}, for logging without a JSON library
+func (g *AdapterGenerator) generateTypesIO(info *types.TypeInfo) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("// Auto-generated by json2cpp\n")
+	buf.WriteString("// Debug printing of the types in types.h (parser-independent)\n\n")
+	buf.WriteString("#ifndef JSON2CPP_TYPES_IO_H\n")
+	buf.WriteString("#define JSON2CPP_TYPES_IO_H\n\n")
+	buf.WriteString("#include \"types.h\"\n")
+	buf.WriteString("#include <ostream>\n\n")
+
+	if g.namespace != "" {
+		buf.WriteString(fmt.Sprintf("namespace %s {\n\n", g.namespace))
+	}
+
+	buf.WriteString(printHelpers)
+	buf.WriteString("\n")
+	if usesFormat(info, types.FormatDecimal) {
+		buf.WriteString(printDecimal)
+		buf.WriteString("\n")
+	}
+	templates := []cppTemplate{printGeneric, printVector}
+	if g.usesOptional(info) {
+		templates = append(templates, printOptional)
+	}
+	if g.usesNullable(info) {
+		templates = append(templates, printNullable)
+	}
+	if g.useVariant() && (hasUnions(info) || usesVariants(info)) {
+		templates = append(templates, printVariant)
+	}
+	writeTemplates(&buf, templates)
+
+	// Same order as types.h, so nested structs print first
+	for i := len(info.Structs) - 1; i >= 0; i-- {
+		buf.WriteString("\n")
+		buf.WriteString(g.generatePrintOperator(info.Structs[i]))
+	}
+
+	if g.namespace != "" {
+		buf.WriteString("\n} // namespace ")
+		buf.WriteString(g.namespace)
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("\n#endif // JSON2CPP_TYPES_IO_H\n")
+
+	return buf.String()
+}
+
+// generatePrintOperator returns the operator<< of a struct. Members of base
+// structs print first, as if declared in the struct; a tagged union prints
+// the variant it holds.
+func (g *AdapterGenerator) generatePrintOperator(s *types.Struct) string {
+	var buf bytes.Buffer
+	g.usedNames = make(map[string]int)
+
+	fields := flattenedFields(s)
+
+	if s.Union == nil && len(fields) == 0 {
+		buf.WriteString(fmt.Sprintf("inline std::ostream& operator<<(std::ostream& os, const %s&) {\n", s.Name))
+		buf.WriteString(fmt.Sprintf("    return os << \"%s{}\";\n", s.Name))
+		buf.WriteString("}\n")
+		return buf.String()
+	}
+
+	buf.WriteString(fmt.Sprintf("inline std::ostream& operator<<(std::ostream& os, const %s& obj) {\n", s.Name))
+	buf.WriteString(fmt.Sprintf("    os << \"%s{\";\n", s.Name))
+
+	switch {
+	case s.Union != nil && g.useVariant():
+		buf.WriteString("    PrintValue(os, obj.value);\n")
+	case s.Union != nil:
+		tagMember, members := g.unionMembers(s)
+		buf.WriteString(fmt.Sprintf("    os << \"%s=\";\n", tagMember))
+		buf.WriteString(fmt.Sprintf("    PrintValue(os, obj.%s);\n", tagMember))
+		for i, v := range s.Union.Variants {
+			if i == 0 {
+				buf.WriteString(fmt.Sprintf("    if (obj.%s == %s) {\n", tagMember, cppStringLiteral(v.Value)))
+			} else {
+				buf.WriteString(fmt.Sprintf("    } else if (obj.%s == %s) {\n", tagMember, cppStringLiteral(v.Value)))
+			}
+			member := members[i]
+			buf.WriteString(fmt.Sprintf("        os << \", %s=\";\n", member))
+			buf.WriteString(fmt.Sprintf("        PrintValue(os, obj.%s);\n", member))
+		}
+		if len(s.Union.Variants) > 0 {
+			buf.WriteString("    }\n")
+		}
+	default:
+		for i, f := range fields {
+			separator := ", "
+			if i == 0 {
+				separator = ""
+			}
+			buf.WriteString(fmt.Sprintf("    os << \"%s%s=\";\n", separator, g.getFieldName(f.Name)))
+			buf.WriteString(fmt.Sprintf("    PrintValue(os, %s);\n", g.memberAccess(f, "obj")))
+		}
+	}
+
+	buf.WriteString("    return os << \"}\";\n")
+	buf.WriteString("}\n")
+	return buf.String()
+}
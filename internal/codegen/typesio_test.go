@@ -0,0 +1,118 @@
+package codegen
+
+import (
+	"bytes"
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestGeneratePrintOperator(t *testing.T) {
+	grand := &types.Struct{Name: "Entity", Fields: []*types.Field{{Name: "id", Type: types.JSONInt}}}
+	parent := &types.Struct{Name: "Audit", Base: grand, Fields: []*types.Field{{Name: "created", Type: types.JSONString}}}
+	shape := &types.Struct{Name: "Shape", Union: &types.Union{Tag: "type", Variants: []*types.Variant{
+		{Value: "circle"}, {Value: "square"},
+	}}}
+
+	tests := []struct {
+		name   string
+		s      *types.Struct
+		cppStd int
+		want   string
+	}{
+		{"no members", &types.Struct{Name: "E"}, 11,
+			"inline std::ostream& operator<<(std::ostream& os, const E&) {\n" +
+				"    return os << \"E{}\";\n}\n"},
+		{"members", &types.Struct{Name: "P", Fields: []*types.Field{
+			{Name: "x", Type: types.JSONInt}, {Name: "label", Type: types.JSONString},
+		}}, 11,
+			"inline std::ostream& operator<<(std::ostream& os, const P& obj) {\n" +
+				"    os << \"P{\";\n" +
+				"    os << \"x=\";\n" +
+				"    PrintValue(os, obj.x);\n" +
+				"    os << \", label=\";\n" +
+				"    PrintValue(os, obj.label);\n" +
+				"    return os << \"}\";\n}\n"},
+		// Members of every base print first, outermost base first
+		{"base chain", &types.Struct{Name: "User", Base: parent, Fields: []*types.Field{
+			{Name: "name", Type: types.JSONString},
+		}}, 11,
+			"    os << \"User{\";\n" +
+				"    os << \"id=\";\n" +
+				"    PrintValue(os, obj.id);\n" +
+				"    os << \", created=\";\n" +
+				"    PrintValue(os, obj.created);\n" +
+				"    os << \", name=\";\n" +
+				"    PrintValue(os, obj.name);\n"},
+		{"tagged union", shape, 11,
+			"    os << \"Shape{\";\n" +
+				"    os << \"type=\";\n" +
+				"    PrintValue(os, obj.type);\n" +
+				"    if (obj.type == \"circle\") {\n" +
+				"        os << \", circle=\";\n" +
+				"        PrintValue(os, obj.circle);\n" +
+				"    } else if (obj.type == \"square\") {\n" +
+				"        os << \", square=\";\n" +
+				"        PrintValue(os, obj.square);\n" +
+				"    }\n" +
+				"    return os << \"}\";\n"},
+		{"std::variant union", shape, 17,
+			"    os << \"Shape{\";\n" +
+				"    PrintValue(os, obj.value);\n" +
+				"    return os << \"}\";\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := NewAdapterGenerator(Config{CppStandard: tt.cppStd}, "").generatePrintOperator(tt.s)
+			checkOutput(t, out, []string{tt.want}, nil)
+		})
+	}
+}
+
+func TestGenerateTypesIO(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		field   types.Field
+		want    []string
+		notWant []string
+	}{
+		{"no JSON library", Config{Parser: ParserNlohmann},
+			types.Field{Name: "id", Type: types.JSONInt},
+			[]string{"#include \"types.h\"\n#include <ostream>\n\n" + printHelpers},
+			[]string{"nlohmann", printDecimal, printOptional.declaration, printNullable.declaration}},
+		{"namespace", Config{Namespace: "api"},
+			types.Field{Name: "id", Type: types.JSONInt},
+			[]string{"namespace api {\n\n" + printHelpers, "} // namespace api\n\n#endif // JSON2CPP_TYPES_IO_H\n"},
+			nil},
+		{"int8 prints as a number", Config{},
+			types.Field{Name: "level", Type: types.JSONInt, CppType: "int8_t"},
+			[]string{"inline void PrintValue(std::ostream& os, int8_t value) {\n    os << static_cast<int>(value);\n}\n"}, nil},
+		{"Decimal prints its exact text", Config{},
+			types.Field{Name: "price", Type: types.JSONFloat, Format: types.FormatDecimal},
+			[]string{printDecimal}, nil},
+		{"Optional", Config{OptionalNull: true},
+			types.Field{Name: "name", Type: types.JSONString, IsOptional: true},
+			[]string{printOptional.definition}, []string{printNullable.declaration}},
+		{"Nullable", Config{Nullable: true},
+			types.Field{Name: "note", Type: types.JSONString, Nullable: true},
+			[]string{printNullable.definition}, []string{printOptional.declaration}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field := tt.field
+			info := &types.TypeInfo{Structs: []*types.Struct{{Name: "Root", Fields: []*types.Field{&field}}}}
+			checkOutput(t, NewAdapterGenerator(tt.cfg, "").generateTypesIO(info), tt.want, tt.notWant)
+		})
+	}
+}
+
+func TestWriteTemplates(t *testing.T) {
+	var buf bytes.Buffer
+	writeTemplates(&buf, []cppTemplate{{"void A()", "\nvoid A() {}\n"}, {"void B()", "\nvoid B() {}\n"}})
+	if want := "void A();\nvoid B();\n\nvoid A() {}\n\nvoid B() {}\n"; buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,148 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+
+	"json2cpp/internal/types"
+)
+
+func TestUnionMembers(t *testing.T) {
+	tests := []struct {
+		name      string
+		camelCase bool
+		tag       string
+		values    []string
+		want      string
+	}{
+		{"distinct", false, "type", []string{"circle", "square"}, "type: circle, square"},
+		{"value named like the tag", false, "kind", []string{"kind", "other"}, "kind: kind2, other"},
+		{"values mapping to one name", false, "type", []string{"a-b", "a_b", "a b"}, "type: a_b, a_b2, a_b3"},
+		{"suffixes count up", false, "type", []string{"x", "x!", "x?"}, "type: x, x2, x3"},
+		{"camelCase", true, "shape_type", []string{"big_circle", "bigCircle"}, "shapeType: bigCircle, bigCircle2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			union := &types.Union{Tag: tt.tag}
+			for _, v := range tt.values {
+				union.Variants = append(union.Variants, &types.Variant{Value: v})
+			}
+			tagMember, members := NewAdapterGenerator(Config{CamelCase: tt.camelCase}, "").unionMembers(&types.Struct{Name: "U", Union: union})
+			if got := tagMember + ": " + strings.Join(members, ", "); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnionSerializers(t *testing.T) {
+	shape := func() *types.Struct {
+		return &types.Struct{Name: "Shape", Union: &types.Union{Tag: "type", Variants: []*types.Variant{
+			{Value: "circle", Type: &types.Struct{Name: "Circle"}},
+			{Value: "square", Type: &types.Struct{Name: "Square"}},
+		}}}
+	}
+
+	tests := []struct {
+		name   string
+		parser ParserType
+		cppStd int
+		read   string
+		write  string
+	}{
+		{"rapidjson tag and members", ParserRapidJSON, 11,
+			"    if (!(json.HasMember(\"type\") && json[\"type\"].IsString())) {\n" +
+				"        return;\n" +
+				"    }\n" +
+				"    const std::string tag = json[\"type\"].GetString();\n" +
+				"    obj.type = tag;\n" +
+				"    if (tag == \"circle\") {\n" +
+				"        DeserializeCircle(obj.circle, json);\n" +
+				"    } else if (tag == \"square\") {\n" +
+				"        DeserializeSquare(obj.square, json);\n" +
+				"    }\n",
+			"    if (obj.type == \"circle\") {\n" +
+				"        SerializeCircle(obj.circle, json, allocator);\n" +
+				"    } else if (obj.type == \"square\") {\n" +
+				"        SerializeSquare(obj.square, json, allocator);\n" +
+				"    }\n"},
+		{"nlohmann tag and members", ParserNlohmann, 11,
+			"    const std::string tag = json[\"type\"].get<std::string>();\n" +
+				"    obj.type = tag;\n",
+			"    if (obj.type == \"circle\") {\n" +
+				"        SerializeCircle(obj.circle, json);\n"},
+		{"jsoncpp tag and members", ParserJsonCpp, 11,
+			"    if (!(json.isMember(\"type\") && json[\"type\"].isString())) {\n" +
+				"        return;\n" +
+				"    }\n" +
+				"    const std::string tag = json[\"type\"].asString();\n",
+			"    } else if (obj.type == \"square\") {\n" +
+				"        SerializeSquare(obj.square, json);\n"},
+		{"rapidjson std::variant", ParserRapidJSON, 17,
+			"    if (tag == \"circle\") {\n" +
+				"        Circle value;\n" +
+				"        DeserializeCircle(value, json);\n" +
+				"        obj.value = value;\n",
+			"    switch (obj.value.index()) {\n" +
+				"    case 0: // type == \"circle\"\n" +
+				"        SerializeCircle(std::get<0>(obj.value), json, allocator);\n" +
+				"        break;\n" +
+				"    case 1: // type == \"square\"\n" +
+				"        SerializeSquare(std::get<1>(obj.value), json, allocator);\n" +
+				"        break;\n" +
+				"    }\n"},
+		{"nlohmann std::variant", ParserNlohmann, 17,
+			"        obj.value = value;\n",
+			"        SerializeSquare(std::get<1>(obj.value), json);\n"},
+		{"jsoncpp std::variant", ParserJsonCpp, 17,
+			"        obj.value = value;\n",
+			"        SerializeCircle(std::get<0>(obj.value), json);\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewAdapterGenerator(Config{Parser: tt.parser, CppStandard: tt.cppStd}, "")
+			s := shape()
+			notWant := []string{"obj.value"}
+			if tt.cppStd >= 17 {
+				notWant = []string{"obj.type", "obj.circle"}
+			}
+			checkOutput(t, g.generateUnionDeserializeBody(s), []string{tt.read}, notWant)
+			checkOutput(t, g.generateUnionSerializeBody(s), []string{tt.write}, notWant)
+		})
+	}
+}
+
+// Tag values are escaped in string literals and comments, so that no value
+// ends a literal or a comment line early
+func TestUnionTagEscaping(t *testing.T) {
+	odd := "a\"b\\c\n"
+	shape := &types.Struct{Name: "Shape", Union: &types.Union{Tag: "ty\npe", Variants: []*types.Variant{
+		{Value: odd, Type: &types.Struct{Name: "Odd"}},
+	}}}
+	literal := `"a\"b\\c\n"`
+
+	tests := []struct {
+		name string
+		out  func(g *AdapterGenerator) string
+		want string
+	}{
+		{"read", func(g *AdapterGenerator) string { return g.generateUnionDeserializeBody(shape) },
+			"    if (tag == " + literal + ") {\n"},
+		{"write", func(g *AdapterGenerator) string { return g.generateUnionSerializeBody(shape) },
+			"    if (obj.ty_pe == " + literal + ") {\n"},
+		{"write std::variant", func(g *AdapterGenerator) string {
+			g.cppStandard = 17
+			return g.generateUnionSerializeBody(shape)
+		}, "    case 0: // ty\\npe == " + literal + "\n"},
+		{"struct comment", func(g *AdapterGenerator) string { return g.generateUnionStruct(shape) },
+			"// Tagged union on \"ty\\npe\"\n"},
+		{"print", func(g *AdapterGenerator) string { return g.generatePrintOperator(shape) },
+			"    if (obj.ty_pe == " + literal + ") {\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkOutput(t, tt.out(NewAdapterGenerator(Config{}, "")), []string{tt.want}, []string{odd})
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"json2cpp/internal/types"
+	"strings"
+)
+
+// isVariant reports whether a field holds one of several scalar kinds, or is
+// an array of such values (types.PolicyVariant)
+func isVariant(f *types.Field) bool {
+	if f.Type == types.JSONArray {
+		return f.NestedType == nil && f.ElemType == types.JSONVariant
+	}
+	return f.Type == types.JSONVariant
+}
+
+// usesVariants reports whether any field is a variant of scalar kinds
+func usesVariants(info *types.TypeInfo) bool {
+	for _, s := range info.Structs {
+		for _, f := range s.Fields {
+			if isVariant(f) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// variantKindType returns the C++ alternative for a scalar kind
+func variantKindType(t types.JSONType) string {
+	switch t {
+	case types.JSONBool:
+		return "bool"
+	case types.JSONInt:
+		return "int64_t"
+	case types.JSONFloat:
+		return "double"
+	default:
+		return "std::string"
+	}
+}
+
+// variantCppType returns the std::variant holding the kinds of a field, or
+// of its elements for an array
+func (g *AdapterGenerator) variantCppType(f *types.Field) (string, error) {
+	if !g.useVariant() {
+		return "", fmt.Errorf("field %s holds a variant of %s, which needs --std 17", f.JSONName, types.VariantKindNames(f.Variants))
+	}
+	alternatives := make([]string, 0, len(f.Variants))
+	for _, t := range f.Variants {
+		alternatives = append(alternatives, variantKindType(t))
+	}
+	variant := fmt.Sprintf("std::variant<%s>", strings.Join(alternatives, ", "))
+	if f.Type == types.JSONArray {
+		return fmt.Sprintf("std::vector<%s>", variant), nil
+	}
+	return variant, nil
+}
+
+// variantRead returns the check for a JSON value of kind t and the
+// expression reading it, for the parser backend
+func (g *AdapterGenerator) variantRead(t types.JSONType, value string) (string, string) {
+	switch g.parser {
+	case ParserRapidJSON:
+		switch t {
+		case types.JSONBool:
+			return value + ".IsBool()", value + ".GetBool()"
+		case types.JSONInt:
+			return value + ".IsInt64()", value + ".GetInt64()"
+		case types.JSONFloat:
+			return value + ".IsNumber()", value + ".GetDouble()"
+		default:
+			return value + ".IsString()", fmt.Sprintf("std::string(%s.GetString(), %s.GetStringLength())", value, value)
+		}
+	case ParserNlohmann:
+		switch t {
+		case types.JSONBool:
+			return value + ".is_boolean()", value + ".get<bool>()"
+		case types.JSONInt:
+			return value + ".is_number_integer()", value + ".get<int64_t>()"
+		case types.JSONFloat:
+			return value + ".is_number()", value + ".get<double>()"
+		default:
+			return value + ".is_string()", value + ".get<std::string>()"
+		}
+	default:
+		switch t {
+		case types.JSONBool:
+			return value + ".isBool()", value + ".asBool()"
+		case types.JSONInt:
+			return value + ".isInt64()", value + ".asInt64()"
+		case types.JSONFloat:
+			return value + ".isNumeric()", value + ".asDouble()"
+		default:
+			return value + ".isString()", value + ".asString()"
+		}
+	}
+}
+
+// variantWrite returns the JSON value for the alternative pointed to by ptr
+func (g *AdapterGenerator) variantWrite(t types.JSONType, ptr string) string {
+	switch {
+	case g.parser == ParserRapidJSON && t == types.JSONString:
+		return fmt.Sprintf("rapidjson::Value(%s->c_str(), allocator)", ptr)
+	case g.parser == ParserJsonCpp && t == types.JSONInt:
+		return fmt.Sprintf("static_cast<Json::Int64>(*%s)", ptr)
+	default:
+		return "*" + ptr
+	}
+}
+
+// writeVariantRead writes the if-chain storing value into the alternative of
+// its kind; store formats the statement for an alternative type and a read
+// expression.
+func (g *AdapterGenerator) writeVariantRead(buf *bytes.Buffer, f *types.Field, indent, value string, store func(cppType, expr string) string) {
+	for i, t := range f.Variants {
+		check, read := g.variantRead(t, value)
+		if i == 0 {
+			buf.WriteString(fmt.Sprintf("%sif (%s) {\n", indent, check))
+		} else {
+			buf.WriteString(fmt.Sprintf("%s} else if (%s) {\n", indent, check))
+		}
+		buf.WriteString(fmt.Sprintf("%s    %s\n", indent, store(variantKindType(t), read)))
+	}
+	buf.WriteString(indent + "}\n")
+}
+
+// writeVariantWrite writes the if-chain on the alternative held by variant;
+// emit formats the statement for a JSON value.
+func (g *AdapterGenerator) writeVariantWrite(buf *bytes.Buffer, f *types.Field, indent, variant string, emit func(value string) string) {
+	for i, t := range f.Variants {
+		cppType := variantKindType(t)
+		cond := fmt.Sprintf("const %s* value = std::get_if<%s>(&%s)", cppType, cppType, variant)
+		if i == 0 {
+			buf.WriteString(fmt.Sprintf("%sif (%s) {\n", indent, cond))
+		} else {
+			buf.WriteString(fmt.Sprintf("%s} else if (%s) {\n", indent, cond))
+		}
+		buf.WriteString(fmt.Sprintf("%s    %s\n", indent, emit(g.variantWrite(t, "value"))))
+	}
+	buf.WriteString(indent + "}\n")
+}
+
+// generateDeserializeVariant generates deserialization code for a variant
+// field or array of variants
+func (g *AdapterGenerator) generateDeserializeVariant(f *types.Field) (string, error) {
+	var buf bytes.Buffer
+	member := g.targetRef(f)
+	jsonName := f.JSONName
+
+	var has, isArray, valueType string
+	switch g.parser {
+	case ParserRapidJSON:
+		has, isArray, valueType = "HasMember", "IsArray", "rapidjson::Value"
+	case ParserNlohmann:
+		has, isArray, valueType = "contains", "is_array", "nlohmann::json"
+	case ParserJsonCpp:
+		has, isArray, valueType = "isMember", "isArray", "Json::Value"
+	default:
+		return "", fmt.Errorf("unsupported parser: %v", g.parser)
+	}
+
+	if f.Type != types.JSONArray {
+		buf.WriteString(fmt.Sprintf("    if (json.%s(\"%s\")) {\n", has, jsonName))
+		buf.WriteString(fmt.Sprintf("        const %s& value = json[\"%s\"];\n", valueType, jsonName))
+		g.writeVariantRead(&buf, f, "        ", "value", func(cppType, expr string) string {
+			return fmt.Sprintf("%s.emplace<%s>(%s);", member, cppType, expr)
+		})
+		buf.WriteString("    }\n")
+		return buf.String(), nil
+	}
+
+	buf.WriteString(fmt.Sprintf("    if (json.%s(\"%s\") && json[\"%s\"].%s()) {\n", has, jsonName, jsonName, isArray))
+	buf.WriteString(fmt.Sprintf("        %s.clear();\n", member))
+	switch g.parser {
+	case ParserRapidJSON:
+		buf.WriteString(fmt.Sprintf("        const rapidjson::Value& arr = json[\"%s\"];\n", jsonName))
+		buf.WriteString("        for (rapidjson::SizeType i = 0; i < arr.Size(); ++i) {\n")
+		buf.WriteString("            const rapidjson::Value& value = arr[i];\n")
+	case ParserNlohmann:
+		buf.WriteString(fmt.Sprintf("        for (const auto& value : json[\"%s\"]) {\n", jsonName))
+	case ParserJsonCpp:
+		buf.WriteString(fmt.Sprintf("        const Json::Value& arr = json[\"%s\"];\n", jsonName))
+		buf.WriteString("        for (Json::ArrayIndex i = 0; i < arr.size(); ++i) {\n")
+		buf.WriteString("            const Json::Value& value = arr[i];\n")
+	}
+	g.writeVariantRead(&buf, f, "            ", "value", func(cppType, expr string) string {
+		return fmt.Sprintf("%s.emplace_back(std::in_place_type<%s>, %s);", member, cppType, expr)
+	})
+	buf.WriteString("        }\n")
+	buf.WriteString("    }\n")
+	return buf.String(), nil
+}
+
+// generateSerializeVariant generates serialization code for a variant field
+// or array of variants
+func (g *AdapterGenerator) generateSerializeVariant(f *types.Field) (string, error) {
+	var buf bytes.Buffer
+	member := g.memberRef(f)
+	jsonName := f.JSONName
+
+	if f.Type != types.JSONArray {
+		g.writeVariantWrite(&buf, f, "    ", member, func(value string) string {
+			if g.parser == ParserRapidJSON {
+				return fmt.Sprintf("json.AddMember(\"%s\", %s, allocator);", jsonName, value)
+			}
+			return fmt.Sprintf("json[\"%s\"] = %s;", jsonName, value)
+		})
+		return buf.String(), nil
+	}
+
+	switch g.parser {
+	case ParserRapidJSON:
+		buf.WriteString("    {\n")
+		buf.WriteString("        rapidjson::Value arr(rapidjson::kArrayType);\n")
+		buf.WriteString(fmt.Sprintf("        for (const auto& item : %s) {\n", member))
+		g.writeVariantWrite(&buf, f, "            ", "item", func(value string) string {
+			return fmt.Sprintf("arr.PushBack(%s, allocator);", value)
+		})
+		buf.WriteString("        }\n")
+		buf.WriteString(fmt.Sprintf("        json.AddMember(\"%s\", arr, allocator);\n", jsonName))
+		buf.WriteString("    }\n")
+	case ParserNlohmann:
+		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = nlohmann::json::array();\n", jsonName))
+		buf.WriteString(fmt.Sprintf("    for (const auto& item : %s) {\n", member))
+		g.writeVariantWrite(&buf, f, "        ", "item", func(value string) string {
+			return fmt.Sprintf("json[\"%s\"].push_back(%s);", jsonName, value)
+		})
+		buf.WriteString("    }\n")
+	case ParserJsonCpp:
+		buf.WriteString(fmt.Sprintf("    json[\"%s\"] = Json::Value(Json::arrayValue);\n", jsonName))
+		buf.WriteString(fmt.Sprintf("    for (const auto& item : %s) {\n", member))
+		g.writeVariantWrite(&buf, f, "        ", "item", func(value string) string {
+			return fmt.Sprintf("json[\"%s\"].append(%s);", jsonName, value)
+		})
+		buf.WriteString("    }\n")
+	default:
+		return "", fmt.Errorf("unsupported parser: %v", g.parser)
+	}
+	return buf.String(), nil
+}
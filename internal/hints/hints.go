@@ -0,0 +1,206 @@
+package hints
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"json2cpp/internal/types"
+)
+
+// Hint overrides inference for the fields it matches.
+type Hint struct {
+	// Type selects a representation: "decimal", "base64", "base64url",
+	// "int-string" or "float-string", or a sized number type such as
+	// "uint32" or "float" (see ScalarTypes).
+	Type string `json:"type,omitempty"`
+	// Name replaces the C++ member name derived from the JSON key.
+	Name string `json:"name,omitempty"`
+	// Optional marks the field optional even if every sample has it.
+	Optional bool `json:"optional,omitempty"`
+	// Promotion names the policy for kinds of the field that do not join
+	// (see types.PolicyByName), overriding the default.
+	Promotion string `json:"promotion,omitempty"`
+	// Default is the value of the member when the key is missing: a JSON
+	// boolean, number or string.
+	Default interface{} `json:"default,omitempty"`
+}
+
+// ScalarTypes maps the number type names accepted in a hint to C++ types.
+var ScalarTypes = map[string]string{
+	"int8":   "int8_t",
+	"int16":  "int16_t",
+	"int32":  "int32_t",
+	"int64":  "int64_t",
+	"uint8":  "uint8_t",
+	"uint16": "uint16_t",
+	"uint32": "uint32_t",
+	"uint64": "uint64_t",
+	"float":  "float",
+	"double": "double",
+}
+
+// intBounds holds the range of each integer type of ScalarTypes.
+var intBounds = map[string][2]float64{
+	"int8":   {math.MinInt8, math.MaxInt8},
+	"int16":  {math.MinInt16, math.MaxInt16},
+	"int32":  {math.MinInt32, math.MaxInt32},
+	"int64":  {math.MinInt64, math.MaxInt64},
+	"uint8":  {0, math.MaxUint8},
+	"uint16": {0, math.MaxUint16},
+	"uint32": {0, math.MaxUint32},
+	"uint64": {0, math.MaxUint64},
+}
+
+// Fits reports whether the JSON number n is a value of the integer type
+// named typ, within the precision of a float64; it holds for any other type.
+func Fits(typ string, n float64) bool {
+	bounds, ok := intBounds[typ]
+	if !ok {
+		return true
+	}
+	return n == math.Trunc(n) && n >= bounds[0] && n <= bounds[1]
+}
+
+// Hints is the content of a hints file:
+//
+//	{
+//	  "promotion": "widening",
+//	  "fields": {
+//	    "price":            {"type": "decimal"},
+//	    "Order.total":      {"type": "decimal"},
+//	    "/items/*/amount":  {"type": "decimal"},
+//	    "thumbnail":        {"type": "base64"},
+//	    "Order.qty":        {"type": "uint32", "name": "quantity"},
+//	    "Event.value":      {"promotion": "variant"},
+//	    "Config.retries":   {"default": 3}
+//	  }
+//	}
+//
+// Promotion is the default promotion policy for kinds that do not join.
+//
+// A key starting with "/" is a JSON Pointer pattern matched against the
+// field's pointer in the sample ("*" matches one segment, e.g. an array
+// index). "Struct.key" matches a key of one struct, and any other key is a
+// glob pattern matched against the JSON key alone. Pointer patterns take
+// precedence over struct keys, which take precedence over plain keys.
+type Hints struct {
+	Promotion string          `json:"promotion,omitempty"`
+	Fields    map[string]Hint `json:"fields"`
+}
+
+// Load reads and validates a hints file.
+func Load(filename string) (*Hints, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hints file %s: %w", filename, err)
+	}
+
+	var h Hints
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse hints file %s: %w", filename, err)
+	}
+
+	if _, err := types.PolicyByName(h.Promotion); err != nil {
+		return nil, fmt.Errorf("hints file %s: %w", filename, err)
+	}
+	for key, hint := range h.Fields {
+		if err := hint.validate(); err != nil {
+			return nil, fmt.Errorf("hint %q: %w", key, err)
+		}
+	}
+	return &h, nil
+}
+
+// FromValue decodes a hint given inline as a JSON object, e.g. the value of
+// an in-sample "$json2cpp" annotation.
+func FromValue(v interface{}) (Hint, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Hint{}, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var h Hint
+	if err := dec.Decode(&h); err != nil {
+		return Hint{}, err
+	}
+	if err := h.validate(); err != nil {
+		return Hint{}, err
+	}
+	return h, nil
+}
+
+func (h Hint) validate() error {
+	if _, err := types.PolicyByName(h.Promotion); err != nil {
+		return err
+	}
+	switch h.Default.(type) {
+	case nil, bool, float64, string:
+	default:
+		return fmt.Errorf("default must be a boolean, number or string")
+	}
+	if _, ok := ScalarTypes[h.Type]; ok {
+		return nil
+	}
+	switch h.Type {
+	case "", "decimal", "base64", "base64url", "int-string", "float-string":
+		return nil
+	default:
+		return fmt.Errorf("unknown type %q", h.Type)
+	}
+}
+
+// Lookup returns the hint for a field of structName with the given JSON key
+// and JSON Pointer. Matching is deterministic: within one kind of key the
+// lexically smallest matching pattern wins.
+func (h *Hints) Lookup(structName, jsonName, pointer string) (Hint, bool) {
+	if h == nil {
+		return Hint{}, false
+	}
+
+	if hint, ok := h.match(func(key string) bool {
+		return strings.HasPrefix(key, "/") && MatchPointer(key, pointer)
+	}); ok {
+		return hint, true
+	}
+	if hint, ok := h.Fields[structName+"."+jsonName]; ok {
+		return hint, true
+	}
+	return h.match(func(key string) bool {
+		return !strings.HasPrefix(key, "/") && !strings.Contains(key, ".") && MatchKey(key, jsonName)
+	})
+}
+
+func (h *Hints) match(accept func(key string) bool) (Hint, bool) {
+	keys := make([]string, 0, len(h.Fields))
+	for key := range h.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if accept(key) {
+			return h.Fields[key], true
+		}
+	}
+	return Hint{}, false
+}
+
+// MatchKey reports whether a JSON key matches a glob pattern.
+func MatchKey(pattern, key string) bool {
+	ok, err := path.Match(pattern, key)
+	return err == nil && ok
+}
+
+// MatchPointer reports whether a JSON Pointer matches a pointer pattern in
+// which "*" matches any single segment.
+func MatchPointer(pattern, pointer string) bool {
+	ok, err := path.Match(pattern, pointer)
+	return err == nil && ok
+}
@@ -0,0 +1,70 @@
+package hints
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	h := &Hints{Fields: map[string]Hint{
+		"price":           {Type: "decimal"},
+		"*_amount":        {Type: "decimal"},
+		"Order.total":     {Type: "decimal"},
+		"/items/*/weight": {Type: "decimal"},
+	}}
+
+	tests := []struct {
+		name       string
+		structName string
+		jsonName   string
+		pointer    string
+		want       bool
+	}{
+		{"plain key", "Root", "price", "/price", true},
+		{"key glob", "Root", "tax_amount", "/tax_amount", true},
+		{"struct key", "Order", "total", "/order/total", true},
+		{"struct key other struct", "Invoice", "total", "/invoice/total", false},
+		{"pointer pattern", "ItemsItem", "weight", "/items/3/weight", true},
+		{"pointer pattern elsewhere", "Root", "weight", "/weight", false},
+		{"no match", "Root", "name", "/name", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got := h.Lookup(tt.structName, tt.jsonName, tt.pointer)
+			if got != tt.want {
+				t.Errorf("Lookup(%q, %q, %q) found = %v, want %v",
+					tt.structName, tt.jsonName, tt.pointer, got, tt.want)
+			}
+		})
+	}
+
+	var none *Hints
+	if _, ok := none.Lookup("Root", "price", "/price"); ok {
+		t.Error("Lookup on nil hints found a hint")
+	}
+}
+
+func TestFits(t *testing.T) {
+	tests := []struct {
+		typ  string
+		n    float64
+		want bool
+	}{
+		{"int8", 127, true},
+		{"int8", -128, true},
+		{"int8", 300, false},
+		{"int8", -129, false},
+		{"uint8", 255, true},
+		{"uint8", -1, false},
+		{"int32", 1.5, false},
+		{"uint32", 4294967296, false},
+		{"int64", 9007199254740993, true},
+		{"uint64", 18446744073709551615, true},
+		{"uint64", -1, false},
+		{"double", 1e300, true},
+	}
+
+	for _, tt := range tests {
+		if got := Fits(tt.typ, tt.n); got != tt.want {
+			t.Errorf("Fits(%q, %v) = %v, want %v", tt.typ, tt.n, got, tt.want)
+		}
+	}
+}
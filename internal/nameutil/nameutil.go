@@ -166,3 +166,144 @@ func isCppKeyword(s string) bool {
 	}
 	return false
 }
+
+// irregularPlurals maps plural English words that do not follow the suffix
+// rules to their singular form.
+var irregularPlurals = map[string]string{
+	"people":   "person",
+	"children": "child",
+	"men":      "man",
+	"women":    "woman",
+	"mice":     "mouse",
+	"geese":    "goose",
+	"feet":     "foot",
+	"teeth":    "tooth",
+	"criteria": "criterion",
+	"indices":  "index",
+	"matrices": "matrix",
+	"vertices": "vertex",
+	"analyses": "analysis",
+	"statuses": "status",
+	"aliases":  "alias",
+	"leaves":   "leaf",
+	"knives":   "knife",
+	"lives":    "life",
+	"wives":    "wife",
+	"halves":   "half",
+	"shelves":  "shelf",
+
+	// -ie words, which the -ies rule would turn into -y
+	"movies":   "movie",
+	"cookies":  "cookie",
+	"zombies":  "zombie",
+	"calories": "calorie",
+	"rookies":  "rookie",
+	"selfies":  "selfie",
+	"goalies":  "goalie",
+	"brownies": "brownie",
+
+	// -che words, and -us and -z words whose plural the suffix rules
+	// cannot tell apart from others
+	"caches":     "cache",
+	"niches":     "niche",
+	"headaches":  "headache",
+	"avalanches": "avalanche",
+	"buses":      "bus",
+	"viruses":    "virus",
+	"campuses":   "campus",
+	"bonuses":    "bonus",
+	"gases":      "gas",
+	"lenses":     "lens",
+	"quizzes":    "quiz",
+	"whizzes":    "whiz",
+}
+
+// uncountableWords have no distinct singular form.
+var uncountableWords = map[string]bool{
+	"data": true, "metadata": true, "info": true, "information": true,
+	"news": true, "series": true, "species": true, "equipment": true,
+	"sheep": true, "fish": true, "media": true,
+}
+
+// Singularize returns the English singular of a lowercase or capitalized
+// word. Words that are not recognisably plural are returned unchanged, so
+// callers can detect the "no singular form" case by comparing the result.
+func Singularize(word string) string {
+	lower := strings.ToLower(word)
+	singular := singularizeLower(lower)
+	if singular == lower {
+		return word
+	}
+	// Restore the capitalization of the first letter
+	if rs := []rune(word); len(rs) > 0 && unicode.IsUpper(rs[0]) {
+		out := []rune(singular)
+		out[0] = unicode.ToUpper(out[0])
+		return string(out)
+	}
+	return singular
+}
+
+func singularizeLower(w string) string {
+	if uncountableWords[w] {
+		return w
+	}
+	if s, ok := irregularPlurals[w]; ok {
+		return s
+	}
+
+	switch {
+	case len(w) <= 4 && strings.HasSuffix(w, "ies"):
+		// ties -> tie, pies -> pie
+		return w[:len(w)-1]
+	case strings.HasSuffix(w, "ies"):
+		// categories -> category; after a vowel (-oies, -uies) the
+		// singular is unclear, so the word is left alone
+		if isVowel(w[len(w)-4]) {
+			return w
+		}
+		return w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "ches"):
+		// matches -> match, churches -> church, coaches -> coach. Other
+		// -ches words may be -che words (caches, niches), so they are left
+		// alone unless listed as irregular.
+		stem := w[:len(w)-2]
+		if strings.HasSuffix(stem, "tch") || strings.HasSuffix(stem, "rch") ||
+			strings.HasSuffix(stem, "nch") || strings.HasSuffix(stem, "lch") ||
+			strings.HasSuffix(stem, "each") || strings.HasSuffix(stem, "oach") ||
+			strings.HasSuffix(stem, "ouch") {
+			return stem
+		}
+		return w
+	case strings.HasSuffix(w, "sses"), strings.HasSuffix(w, "shes"),
+		strings.HasSuffix(w, "xes"), strings.HasSuffix(w, "zzes"),
+		strings.HasSuffix(w, "tzes"):
+		// addresses -> address, dishes -> dish, boxes -> box, buzzes -> buzz
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"), strings.HasSuffix(w, "us"), strings.HasSuffix(w, "is"):
+		// address, status, analysis are already singular
+		return w
+	case len(w) > 1 && strings.HasSuffix(w, "s"):
+		// users -> user, responses -> response, sizes -> size
+		return w[:len(w)-1]
+	default:
+		return w
+	}
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
+
+// SingularizeTypeName singularizes the last word of a PascalCase type name,
+// e.g. "OrderItems" -> "OrderItem" and "UserCategories" -> "UserCategory".
+func SingularizeTypeName(name string) string {
+	runes := []rune(name)
+	start := 0
+	for i := len(runes) - 1; i > 0; i-- {
+		if unicode.IsUpper(runes[i]) {
+			start = i
+			break
+		}
+	}
+	return string(runes[:start]) + Singularize(string(runes[start:]))
+}
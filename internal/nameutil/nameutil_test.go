@@ -96,3 +96,58 @@ func TestIsCppKeyword(t *testing.T) {
 		})
 	}
 }
+
+func TestSingularizeTypeName(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"Users", "User"},
+		{"Categories", "Category"},
+		{"Addresses", "Address"},
+		{"Boxes", "Box"},
+		{"Matches", "Match"},
+		{"People", "Person"},
+		{"Children", "Child"},
+		{"OrderItems", "OrderItem"},
+		{"UserCategories", "UserCategory"},
+		{"Responses", "Response"},
+		{"Queries", "Query"},
+		{"Ties", "Tie"},
+		{"Movies", "Movie"},
+		{"Churches", "Church"},
+		{"Coaches", "Coach"},
+		{"Benches", "Bench"},
+		{"Caches", "Cache"},
+		{"Dishes", "Dish"},
+		{"Buses", "Bus"},
+		{"Courses", "Course"},
+		{"Causes", "Cause"},
+		{"Sizes", "Size"},
+		{"Buzzes", "Buzz"},
+		{"Waltzes", "Waltz"},
+		{"Quizzes", "Quiz"},
+		{"UserMovies", "UserMovie"},
+
+		// Singular unclear: unchanged, so the caller falls back to an
+		// Item suffix
+		{"Sandwiches", "Sandwiches"},
+		{"Quiches", "Quiches"},
+		{"Zoies", "Zoies"},
+
+		// Not plural: unchanged
+		{"Status", "Status"},
+		{"Address", "Address"},
+		{"Data", "Data"},
+		{"News", "News"},
+		{"User", "User"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := SingularizeTypeName(tt.input); got != tt.want {
+				t.Errorf("SingularizeTypeName(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
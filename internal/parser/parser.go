@@ -1,27 +1,93 @@
 package parser
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"json2cpp/internal/hints"
 	"json2cpp/internal/nameutil"
 	"json2cpp/internal/types"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+// Options enables optional inference passes. The zero value keeps the
+// original naming and inference behaviour.
+type Options struct {
+	// SingularNames names the item struct of an array of objects after the
+	// singular of its key ("users" -> User) instead of appending "Item".
+	SingularNames bool
+	// SingularExceptions maps a JSON key to the item struct name to use for
+	// it, overriding the English rules (e.g. "data" -> "Record").
+	SingularExceptions map[string]string
+	// DetectUnions splits arrays of objects carrying a string tag field into
+	// one struct per tag value plus a tagged union wrapper struct. The tag
+	// field is looked up in UnionTags, or DefaultUnionTags when empty.
+	DetectUnions bool
+	UnionTags    []string
+	// DecimalFields maps numbers whose key (glob) or JSON Pointer pattern
+	// matches to the exact fixed-point Decimal type.
+	DecimalFields []string
+	// DetectBase64 maps strings that look like base64 data to byte vectors.
+	DetectBase64 bool
+	// DetectNumericStrings maps strings holding numbers ("42", "0.75") to
+	// int64_t/double members that are still written back as strings.
+	DetectNumericStrings bool
+	// Hints overrides inference for individual fields (see hints.Hints).
+	Hints *hints.Hints
+	// Promotion decides the element kind of arrays mixing kinds that do not
+	// join, unless a hint or annotation names another policy for the field.
+	// nil selects types.PolicyWidening.
+	Promotion types.PromotionPolicy
+}
+
+// DefaultUnionTags are the tag field names tried when union detection is
+// enabled without configured tags.
+var DefaultUnionTags = []string{"type", "kind"}
+
 type Parser struct {
 	structCounter int
 	legacyCpp     bool
 	camelCase     bool
+	opts          Options
+	warnings      []string
+	source        string            // file currently being parsed, recorded as provenance
+	typeNames     map[string]string // struct names used in the current document -> key they came from
+	singulars     map[string][]*types.Field
 }
 
 func NewParser(legacyCpp bool, camelCase bool) *Parser {
+	return NewParserWithOptions(legacyCpp, camelCase, Options{})
+}
+
+// NewParserWithOptions creates a parser with optional inference passes enabled.
+func NewParserWithOptions(legacyCpp bool, camelCase bool, opts Options) *Parser {
 	return &Parser{
 		legacyCpp: legacyCpp,
 		camelCase: camelCase,
+		opts:      opts,
 	}
 }
 
+// Warnings returns the inference warnings collected so far, in the order
+// they were raised.
+func (p *Parser) Warnings() []string {
+	return p.warnings
+}
+
+func (p *Parser) warnf(format string, args ...interface{}) {
+	p.warnings = append(p.warnings, fmt.Sprintf(format, args...))
+}
+
 func (p *Parser) ParseFile(filename string) ([]*types.Struct, error) {
+	return p.ParseFileAs(filename, "Root")
+}
+
+// ParseFileAs parses a file whose root struct is named rootName.
+func (p *Parser) ParseFileAs(filename string, rootName string) ([]*types.Struct, error) {
 	data, err := ioutil.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read file %s: %w", filename, err)
@@ -32,17 +98,29 @@ func (p *Parser) ParseFile(filename string) ([]*types.Struct, error) {
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	return p.ParseValue(v, "Root")
+	p.source = filename
+	defer func() { p.source = "" }()
+	return p.ParseValue(v, rootName)
 }
 
 func (p *Parser) ParseValue(v interface{}, suggestedName string) ([]*types.Struct, error) {
+	p.typeNames = make(map[string]string)
+	p.singulars = make(map[string][]*types.Field)
+	return p.parseValue(v, suggestedName, "")
+}
+
+func (p *Parser) parseValue(v interface{}, suggestedName string, path string) ([]*types.Struct, error) {
 	switch val := v.(type) {
 	case map[string]interface{}:
-		return p.parseObject(val, suggestedName)
+		p.claimStructName(types.GenerateStructName(suggestedName))
+		return p.parseObject(val, suggestedName, path)
 	case []interface{}:
+		if tag := p.unionTag(val); tag != "" {
+			return p.parseTaggedUnion(val, suggestedName+"Item", tag, path)
+		}
 		// 배열의 경우 배열 남용을 위해 첫 번째 요소 분석
 		if len(val) > 0 {
-			return p.ParseValue(val[0], suggestedName+"Item")
+			return p.parseValue(val[0], suggestedName+"Item", path+"/0")
 		}
 		return []*types.Struct{}, nil
 	default:
@@ -51,25 +129,56 @@ func (p *Parser) ParseValue(v interface{}, suggestedName string) ([]*types.Struc
 	}
 }
 
-func (p *Parser) parseObject(obj map[string]interface{}, structName string) ([]*types.Struct, error) {
+func (p *Parser) parseObject(obj map[string]interface{}, structName string, path string) ([]*types.Struct, error) {
 	structs := make([]*types.Struct, 0)
 
 	// 현재 struct 생성
 	current := &types.Struct{
 		Name:   p.generateStructName(structName),
 		Fields: make([]*types.Field, 0),
+		Path:   path,
+	}
+
+	// 결정적인 출력을 위해 키를 정렬해서 순회
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	for key, value := range obj {
+	annotations, err := p.annotations(obj, keys, path)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := annotations[""]; ok && a.Name != "" {
+		current.Name = types.GenerateStructName(a.Name)
+		p.claimStructName(current.Name)
+	}
+
+	for _, key := range keys {
+		if strings.HasSuffix(key, AnnotationKey) {
+			continue
+		}
+		value := obj[key]
+		fieldPath := path + "/" + escapePointerToken(key)
 		field := &types.Field{
 			Name:     p.generateFieldName(key),
 			JSONName: key,
+			Path:     fieldPath,
+		}
+		if p.source != "" {
+			field.Sources = []string{p.source}
+		}
+		field.Pointers = []string{fieldPath}
+		if field.Name != key {
+			field.AddTrace("renamed from %q (nameutil.SanitizeToCppIdentifier)", key)
 		}
 
 		switch val := value.(type) {
 		case nil:
 			field.Type = types.JSONNull
 			field.IsOptional = true
+			field.Nullable = true
 
 		case bool:
 			field.Type = types.JSONBool
@@ -83,33 +192,63 @@ func (p *Parser) parseObject(obj map[string]interface{}, structName string) ([]*
 
 		case string:
 			field.Type = types.JSONString
+			if p.opts.DetectNumericStrings {
+				if format := numericStringFormat(val); format != types.FormatDefault {
+					field.Format = format
+					field.AddTrace("detected %s", format)
+				}
+			}
+			if field.Format == types.FormatDefault && p.opts.DetectBase64 {
+				if format := base64Format(val); format != types.FormatDefault {
+					field.Format = format
+					field.AddTrace("detected %s data", format)
+				}
+			}
 
 		case []interface{}:
 			field.Type = types.JSONArray
 			if len(val) > 0 {
 				// 배열 요소의 타입 분석
-				elemType := p.inferArrayElementType(val)
-				if elemType == types.JSONObject {
+				policy := p.promotion(current.Name, field, annotations[key])
+				elems, nullable, promotions, err := p.inferArrayElementType(val, current.Name+"."+key, policy)
+				if err != nil {
+					return nil, err
+				}
+				field.Trace = append(field.Trace, promotions...)
+				if elems.Type == types.JSONObject {
 					// 객체 배열인 경우 nested struct 생성
-					nestedName := p.generateStructName(key) + "Item"
-					nestedStructs, err := p.parseArrayOfObjects(val, nestedName)
+					nestedName := p.itemStructName(key)
+					var nestedStructs []*types.Struct
+					var err error
+					if tag := p.unionTag(val); tag != "" {
+						nestedStructs, err = p.parseTaggedUnion(val, nestedName, tag, fieldPath)
+					} else {
+						nestedStructs, err = p.parseArrayOfObjects(val, nestedName, fieldPath)
+					}
 					if err != nil {
 						return nil, err
 					}
 					structs = append(structs, nestedStructs...)
 					if len(nestedStructs) > 0 {
-						field.NestedType = nestedStructs[0]
+						field.NestedType = nestedStructs[len(nestedStructs)-1]
+						if nestedName != types.GenerateStructName(key)+"Item" {
+							field.AddTrace("item type %s (singular of %q)", nestedName, key)
+							p.singulars[nestedName] = append(p.singulars[nestedName], field)
+						}
 					}
 				} else {
 					// primitive array element type
-					field.ElemType = elemType
+					field.ElemType = elems.Type
+					field.Variants = elems.Variants
+					field.ElemNullable = nullable
 				}
 			}
 
 		case map[string]interface{}:
 			field.Type = types.JSONObject
 			nestedName := p.generateStructName(key)
-			nestedStructs, err := p.parseObject(val, nestedName)
+			p.claimStructName(nestedName)
+			nestedStructs, err := p.parseObject(val, nestedName, fieldPath)
 			if err != nil {
 				return nil, err
 			}
@@ -117,11 +256,33 @@ func (p *Parser) parseObject(obj map[string]interface{}, structName string) ([]*
 			if len(nestedStructs) > 0 {
 				field.NestedType = nestedStructs[len(nestedStructs)-1]
 			}
+			// the object's own annotation was validated by parseObject
+			if raw, ok := val[AnnotationKey]; ok {
+				if a, err := hints.FromValue(raw); err == nil {
+					if a.Optional {
+						field.IsOptional = true
+						field.AddTrace("annotation: optional")
+					}
+					if a.Promotion != "" {
+						field.Promotion = a.Promotion
+						field.AddTrace("annotation: %s promotion", a.Promotion)
+					}
+				}
+			}
 
 		default:
 			field.Type = types.JSONString // 기본값
 		}
 
+		if example, ok := exampleText(value); ok {
+			field.AddExample(example)
+		}
+
+		p.applyHint(field, current.Name, value)
+		if a, ok := annotations[key]; ok {
+			p.applyDirective(field, current.Name, a, "annotation", value)
+		}
+
 		current.Fields = append(current.Fields, field)
 	}
 
@@ -129,7 +290,7 @@ func (p *Parser) parseObject(obj map[string]interface{}, structName string) ([]*
 	return structs, nil
 }
 
-func (p *Parser) parseArrayOfObjects(arr []interface{}, structName string) ([]*types.Struct, error) {
+func (p *Parser) parseArrayOfObjects(arr []interface{}, structName string, path string) ([]*types.Struct, error) {
 	if len(arr) == 0 {
 		return nil, nil
 	}
@@ -140,47 +301,172 @@ func (p *Parser) parseArrayOfObjects(arr []interface{}, structName string) ([]*t
 		return nil, nil
 	}
 
-	return p.parseObject(first, structName)
+	return p.parseObject(first, structName, path+"/0")
 }
 
-func (p *Parser) inferArrayElementType(arr []interface{}) types.JSONType {
-	if len(arr) == 0 {
-		return types.JSONNull
+// unionTag returns the tag field that discriminates the objects of arr, or ""
+// when union detection is off or arr is not a tagged union: every element
+// must be an object with a string value for the tag, and there must be at
+// least two distinct values.
+func (p *Parser) unionTag(arr []interface{}) string {
+	if !p.opts.DetectUnions {
+		return ""
+	}
+	tags := p.opts.UnionTags
+	if len(tags) == 0 {
+		tags = DefaultUnionTags
+	}
+
+	for _, tag := range tags {
+		values := make(map[string]bool)
+		for _, elem := range arr {
+			obj, ok := elem.(map[string]interface{})
+			if !ok {
+				return ""
+			}
+			value, ok := obj[tag].(string)
+			if !ok {
+				values = nil
+				break
+			}
+			values[value] = true
+		}
+		if len(values) >= 2 {
+			return tag
+		}
+	}
+	return ""
+}
+
+// parseTaggedUnion creates one variant struct per tag value (named after the
+// union and the value, from the first element carrying it) and returns them
+// followed by the union wrapper struct.
+func (p *Parser) parseTaggedUnion(arr []interface{}, structName string, tag string, path string) ([]*types.Struct, error) {
+	structs := make([]*types.Struct, 0)
+	name := types.GenerateStructName(structName)
+	union := &types.Union{Tag: tag}
+	used := make(map[string]bool) // variant struct names, as tag values may sanitize alike
+
+	for i, elem := range arr {
+		obj := elem.(map[string]interface{})
+		value := obj[tag].(string)
+		if union.Variant(value) != nil {
+			continue
+		}
+
+		base := name + types.GenerateStructName(value)
+		variantName := base
+		for n := 2; used[variantName]; n++ {
+			variantName = fmt.Sprintf("%s%d", base, n)
+		}
+		used[variantName] = true
+		p.claimStructName(variantName)
+		variantStructs, err := p.parseObject(obj, variantName, fmt.Sprintf("%s/%d", path, i))
+		if err != nil {
+			return nil, err
+		}
+		structs = append(structs, variantStructs...)
+		union.Variants = append(union.Variants, &types.Variant{
+			Value: value,
+			Type:  variantStructs[len(variantStructs)-1],
+		})
 	}
 
-	// 모든 요소의 타입을 분석
-	typeCounts := make(map[types.JSONType]int)
+	structs = append(structs, &types.Struct{
+		Name:   name,
+		Fields: make([]*types.Field, 0),
+		Path:   path + "/0",
+		Union:  union,
+	})
+	return structs, nil
+}
+
+// inferArrayElementType joins the kinds of all elements on the type lattice
+// (see types.JoinTypes), so [1, 2, 2.5] becomes float rather than int. The
+// second result reports whether null elements were seen next to other kinds,
+// the third lists the promotions applied for `json2cpp explain`.
+// Incompatible mixes are left to policy and raise a warning, or an error when
+// the policy rejects them.
+func (p *Parser) inferArrayElementType(arr []interface{}, context string, policy types.PromotionPolicy) (types.Promotion, bool, []string, error) {
+	result := types.Promotion{Type: types.JSONNull}
+	sawNull := false
+	var mixed []types.JSONType // the first two kinds that did not join
+	var promotions []string
 	for _, elem := range arr {
-		if elem == nil {
-			typeCounts[types.JSONNull]++
-		} else if _, ok := elem.(bool); ok {
-			typeCounts[types.JSONBool]++
-		} else if f, ok := elem.(float64); ok {
-			if isInteger(f) {
-				typeCounts[types.JSONInt]++
-			} else {
-				typeCounts[types.JSONFloat]++
+		t := jsonTypeOf(elem)
+		if t == types.JSONNull {
+			sawNull = true
+			continue
+		}
+		joined, err := types.Promote(policy, result.Type, result.Variants, t, nil)
+		if err != nil {
+			return types.Promotion{}, false, nil, fmt.Errorf("array %s: %w (promotion policy %s)", context, err, policy)
+		}
+		if !joined.Joined && mixed == nil {
+			mixed = []types.JSONType{result.Type, t}
+		}
+		if result.Type != types.JSONNull && joined.Type != result.Type {
+			promotions = append(promotions, fmt.Sprintf("inferArrayElementType: %s + %s -> %s", result.Type, t, joined))
+		}
+		result = joined
+	}
+
+	if mixed != nil {
+		p.warnf("array %s mixes %s and %s elements; using %s", context, mixed[0], mixed[1], result)
+	}
+	nullable := sawNull && result.Type != types.JSONNull
+	if nullable {
+		promotions = append(promotions, fmt.Sprintf("inferArrayElementType: %s + null -> nullable %s", result.Type, result.Type))
+	}
+	return result, nullable, promotions, nil
+}
+
+// exampleText returns a sample value as JSON text. Objects and arrays holding
+// objects have no example; their structs describe them.
+func exampleText(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		return "", false
+	case []interface{}:
+		for _, elem := range val {
+			if _, ok := elem.(map[string]interface{}); ok {
+				return "", false
 			}
-		} else if _, ok := elem.(string); ok {
-			typeCounts[types.JSONString]++
-		} else if _, ok := elem.([]interface{}); ok {
-			typeCounts[types.JSONArray]++
-		} else if _, ok := elem.(map[string]interface{}); ok {
-			typeCounts[types.JSONObject]++
 		}
 	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
 
-	// 가장 많은 타입 선택
-	maxCount := 0
-	var result types.JSONType
-	for t, count := range typeCounts {
-		if count > maxCount {
-			maxCount = count
-			result = t
+// jsonTypeOf classifies a value produced by encoding/json.
+func jsonTypeOf(v interface{}) types.JSONType {
+	switch val := v.(type) {
+	case nil:
+		return types.JSONNull
+	case bool:
+		return types.JSONBool
+	case float64:
+		if isInteger(val) {
+			return types.JSONInt
 		}
+		return types.JSONFloat
+	case string:
+		return types.JSONString
+	case []interface{}:
+		return types.JSONArray
+	case map[string]interface{}:
+		return types.JSONObject
+	default:
+		return types.JSONString
 	}
+}
 
-	return result
+// escapePointerToken escapes a key for use in a JSON Pointer (RFC 6901).
+func escapePointerToken(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
 }
 
 func isInteger(f float64) bool {
@@ -192,6 +478,376 @@ func (p *Parser) generateStructName(key string) string {
 	return types.GenerateStructName(key)
 }
 
+// fieldHint returns the hint for a field: an entry of the hints file, or one
+// derived from the pattern options.
+func (p *Parser) fieldHint(structName string, field *types.Field) (hints.Hint, bool) {
+	if hint, ok := p.opts.Hints.Lookup(structName, field.JSONName, field.Path); ok {
+		return hint, true
+	}
+	for _, pattern := range p.opts.DecimalFields {
+		if hints.MatchKey(pattern, field.JSONName) || hints.MatchPointer(pattern, field.Path) {
+			return hints.Hint{Type: "decimal"}, true
+		}
+	}
+	return hints.Hint{}, false
+}
+
+// promotion returns the promotion policy for a field: the one named by its
+// annotation or hint, or the default of the run.
+func (p *Parser) promotion(structName string, field *types.Field, annotation hints.Hint) types.PromotionPolicy {
+	name := annotation.Promotion
+	if name == "" {
+		if hint, ok := p.fieldHint(structName, field); ok {
+			name = hint.Promotion
+		}
+	}
+	// names were validated when the hint or annotation was decoded
+	if policy, ok := types.PromotionPolicies[name]; ok {
+		return policy
+	}
+	if p.opts.Promotion != nil {
+		return p.opts.Promotion
+	}
+	return types.PolicyWidening
+}
+
+// applyHint applies the hint matching a field holding value, if any.
+func (p *Parser) applyHint(field *types.Field, structName string, value interface{}) {
+	if hint, ok := p.fieldHint(structName, field); ok {
+		p.applyDirective(field, structName, hint, "hint", value)
+	}
+}
+
+// applyDirective applies a hint or in-sample annotation to a field holding
+// value. The type is only applied when it fits the inferred JSON type, and
+// the default when it fits the type; origin names the directive in traces and
+// warnings.
+func (p *Parser) applyDirective(field *types.Field, structName string, hint hints.Hint, origin string, value interface{}) {
+	if hint.Name != "" {
+		field.Name = p.generateFieldName(hint.Name)
+		field.AddTrace("%s: renamed to %s", origin, field.Name)
+	}
+	if hint.Optional && !field.IsOptional {
+		field.IsOptional = true
+		field.AddTrace("%s: optional", origin)
+	}
+	if hint.Promotion != "" {
+		field.Promotion = hint.Promotion
+		field.AddTrace("%s: %s promotion", origin, hint.Promotion)
+	}
+	p.applyType(field, structName, hint, origin, value)
+	if hint.Default != nil {
+		p.applyDefault(field, structName, hint, origin)
+	}
+}
+
+// applyDefault sets the default value of a hint or annotation, once its type
+// has been applied. A default that is not a value of the member, such as a
+// string for a number or a number out of the range of its integer type, is
+// ignored with a warning; a field only seen as null keeps it for the merge.
+func (p *Parser) applyDefault(field *types.Field, structName string, hint hints.Hint, origin string) {
+	// validated as a boolean, number or string
+	data, _ := json.Marshal(hint.Default)
+	if field.Type != types.JSONNull && !defaultFits(field, hint.Default) {
+		kind := field.Type.String()
+		if field.Format != types.FormatDefault {
+			kind = field.Format.String()
+		} else if field.CppType != "" {
+			kind = field.CppType
+		}
+		p.warnf("field %s.%s is %s, ignoring %s default %s", structName, field.JSONName, kind, origin, data)
+		return
+	}
+	field.Default = string(data)
+	field.AddTrace("%s: default %s", origin, field.Default)
+}
+
+// defaultFits reports whether a default value, a boolean, number or string,
+// initializes the member of field. Numbers may be given as strings.
+func defaultFits(field *types.Field, value interface{}) bool {
+	switch field.Format {
+	case types.FormatDefault:
+	case types.FormatDecimal, types.FormatFloatString:
+		_, ok := defaultNumber(value)
+		return ok
+	case types.FormatIntString:
+		n, ok := defaultNumber(value)
+		return ok && hints.Fits("int64", n)
+	default:
+		return false
+	}
+	switch field.Type {
+	case types.JSONBool:
+		_, ok := value.(bool)
+		return ok
+	case types.JSONString:
+		_, ok := value.(string)
+		return ok
+	case types.JSONFloat:
+		_, ok := defaultNumber(value)
+		return ok
+	case types.JSONInt:
+		typ := strings.TrimSuffix(field.CppType, "_t")
+		if typ == "" {
+			typ = "int64"
+		}
+		n, ok := defaultNumber(value)
+		return ok && hints.Fits(typ, n)
+	default:
+		return false
+	}
+}
+
+// defaultNumber returns the finite number held by a default value.
+func defaultNumber(value interface{}) (float64, bool) {
+	n, ok := value.(float64)
+	if s, isString := value.(string); isString {
+		var err error
+		n, err = strconv.ParseFloat(s, 64)
+		ok = err == nil
+	}
+	return n, ok && !math.IsInf(n, 0) && !math.IsNaN(n)
+}
+
+// applyType applies the type of a hint or annotation when it fits the
+// inferred JSON type, and warns otherwise.
+func (p *Parser) applyType(field *types.Field, structName string, hint hints.Hint, origin string, value interface{}) {
+	switch hint.Type {
+	case "":
+
+	case "decimal":
+		if field.Type != types.JSONInt && field.Type != types.JSONFloat {
+			p.warnf("field %s.%s is %s, ignoring decimal %s", structName, field.JSONName, field.Type, origin)
+			return
+		}
+		field.Type = types.JSONFloat
+		field.Format = types.FormatDecimal
+		field.AddTrace("%s: exact Decimal", origin)
+
+	case "base64", "base64url":
+		if field.Type != types.JSONString {
+			p.warnf("field %s.%s is %s, ignoring %s %s", structName, field.JSONName, field.Type, hint.Type, origin)
+			return
+		}
+		field.Format = types.FormatBase64
+		if hint.Type == "base64url" {
+			field.Format = types.FormatBase64URL
+		}
+		field.AddTrace("%s: %s bytes", origin, hint.Type)
+
+	case "int-string", "float-string":
+		if field.Type != types.JSONString {
+			p.warnf("field %s.%s is %s, ignoring %s %s", structName, field.JSONName, field.Type, hint.Type, origin)
+			return
+		}
+		field.Format = types.FormatIntString
+		if hint.Type == "float-string" {
+			field.Format = types.FormatFloatString
+		}
+		field.AddTrace("%s: %s", origin, hint.Type)
+
+	case "float", "double":
+		if field.Type != types.JSONInt && field.Type != types.JSONFloat {
+			p.warnf("field %s.%s is %s, ignoring %s %s", structName, field.JSONName, field.Type, hint.Type, origin)
+			return
+		}
+		field.Type = types.JSONFloat
+		field.CppType = hints.ScalarTypes[hint.Type]
+		field.AddTrace("%s: %s", origin, field.CppType)
+
+	default:
+		n, isNumber := value.(float64)
+		if isNumber && hint.Type == "uint64" && field.Type == types.JSONFloat && n >= 0 && n == math.Trunc(n) {
+			// an integer beyond int64: a uint64 above INT64_MAX
+			field.Type = types.JSONInt
+			field.AddTrace("%s: integer beyond int64", origin)
+		}
+		if field.Type != types.JSONInt {
+			p.warnf("field %s.%s is %s, ignoring %s %s", structName, field.JSONName, field.Type, hint.Type, origin)
+			return
+		}
+		if isNumber && !hints.Fits(hint.Type, n) {
+			p.warnf("field %s.%s holds %s, out of range of %s; ignoring %s", structName, field.JSONName,
+				strconv.FormatFloat(n, 'g', -1, 64), hint.Type, origin)
+			return
+		}
+		field.CppType = hints.ScalarTypes[hint.Type]
+		field.AddTrace("%s: %s", origin, field.CppType)
+	}
+}
+
+// AnnotationKey marks in-sample annotations. A member "$json2cpp" annotates
+// the object holding it and a member "key$json2cpp" the field "key"; both
+// hold a hints.Hint and never become fields themselves.
+const AnnotationKey = "$json2cpp"
+
+// annotations decodes the in-sample annotations of obj, keyed by the JSON key
+// they annotate; the annotation of obj itself is stored under "". Only name,
+// optional and promotion apply to an object.
+func (p *Parser) annotations(obj map[string]interface{}, keys []string, path string) (map[string]hints.Hint, error) {
+	result := make(map[string]hints.Hint)
+	for _, key := range keys {
+		if !strings.HasSuffix(key, AnnotationKey) {
+			continue
+		}
+		pointer := path + "/" + escapePointerToken(key)
+		hint, err := hints.FromValue(obj[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid annotation %s: %w", pointer, err)
+		}
+		target := strings.TrimSuffix(key, AnnotationKey)
+		if target == "" && hint.Type != "" {
+			return nil, fmt.Errorf("invalid annotation %s: type does not apply to an object", pointer)
+		}
+		if _, ok := obj[target]; target != "" && !ok {
+			p.warnf("annotation %s refers to missing field %q", pointer, target)
+			continue
+		}
+		result[target] = hint
+	}
+	return result, nil
+}
+
+// numericStringFormat reports whether s holds a number that survives the
+// round-trip through int64_t or double unchanged: no sign prefix, leading
+// zeros, trailing fraction zeros or exponent spelling that formatting the
+// number would not reproduce.
+func numericStringFormat(s string) types.Format {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if strconv.FormatInt(i, 10) == s {
+			return types.FormatIntString
+		}
+		return types.FormatDefault
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+		if strconv.FormatFloat(f, 'g', -1, 64) == s {
+			return types.FormatFloatString
+		}
+	}
+	return types.FormatDefault
+}
+
+// minBase64Length keeps short words and identifiers from being detected as
+// binary data.
+const minBase64Length = 16
+
+// base64Format reports whether s looks like base64-encoded binary data and in
+// which alphabet. Detection is conservative, as an identifier is easily taken
+// for data: besides decoding cleanly with correct padding, the text must be
+// long enough, mix upper case, lower case and digits, carry padding or a
+// symbol of its alphabet, and decode to bytes that are not printable text.
+// Plain alphanumeric data is left to a base64 hint.
+func base64Format(s string) types.Format {
+	if len(s) < minBase64Length {
+		return types.FormatDefault
+	}
+
+	var upper, lower, digit, standard, urlSafe bool
+	for _, r := range strings.TrimRight(s, "=") {
+		switch {
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r == '+' || r == '/':
+			standard = true
+		case r == '-' || r == '_':
+			urlSafe = true
+		default:
+			return types.FormatDefault
+		}
+	}
+	padded := strings.HasSuffix(s, "=")
+	if !upper || !lower || !(digit || standard || urlSafe) || (standard && urlSafe) {
+		return types.FormatDefault
+	}
+	if !padded && !standard && !urlSafe {
+		return types.FormatDefault
+	}
+
+	var data []byte
+	var err error
+	format := types.FormatBase64
+	if urlSafe {
+		// URL-safe data is usually unpadded (JWTs), but padding must be right
+		format = types.FormatBase64URL
+		if padded {
+			data, err = base64.URLEncoding.DecodeString(s)
+		} else {
+			data, err = base64.RawURLEncoding.DecodeString(s)
+		}
+	} else {
+		data, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil || isPrintableText(data) {
+		return types.FormatDefault
+	}
+	return format
+}
+
+// isPrintableText reports whether data is printable ASCII text, such as the
+// decoding of a word that happens to be valid base64
+func isPrintableText(data []byte) bool {
+	for _, c := range data {
+		if (c < 0x20 || c > 0x7e) && c != '\t' && c != '\n' && c != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// itemStructName names the element struct of an array of objects. With
+// singular names enabled it is the singular of the key, unless the key is not
+// recognisably plural or the singular is already taken by a struct from a
+// different key; then (and by default) the key gets an "Item" suffix.
+func (p *Parser) itemStructName(key string) string {
+	fallback := types.GenerateStructName(key) + "Item"
+	if !p.opts.SingularNames {
+		return fallback
+	}
+
+	singular, ok := p.opts.SingularExceptions[key]
+	if ok {
+		singular = types.GenerateStructName(singular)
+	} else {
+		plural := types.GenerateStructName(key)
+		singular = nameutil.SingularizeTypeName(plural)
+		if singular == plural {
+			return fallback
+		}
+	}
+
+	if origin, used := p.typeNames[singular]; used && origin != key {
+		return fallback
+	}
+	p.typeNames[singular] = key
+	return singular
+}
+
+// claimStructName registers the name of an object struct. When a singular
+// item struct already took the name, that item struct falls back to the
+// "Item" suffix so the two types stay distinct.
+func (p *Parser) claimStructName(name string) {
+	origin, used := p.typeNames[name]
+	if !used {
+		p.typeNames[name] = ""
+		return
+	}
+	if origin == "" {
+		return
+	}
+	for _, f := range p.singulars[name] {
+		if f.NestedType != nil && f.NestedType.Name == name {
+			f.NestedType.Name = types.GenerateStructName(f.JSONName) + "Item"
+			f.AddTrace("item type renamed to %s: %s is taken by another struct", f.NestedType.Name, name)
+		}
+	}
+	delete(p.singulars, name)
+	p.typeNames[name] = ""
+}
+
 func (p *Parser) generateFieldName(key string) string {
 	// Delegate sanitization to centralized helper.
 	return nameutil.SanitizeToCppIdentifier(key, p.camelCase, false)
@@ -0,0 +1,447 @@
+package parser
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"json2cpp/internal/hints"
+	"json2cpp/internal/types"
+)
+
+func TestInferArrayElementType(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		want         types.JSONType
+		wantNullable bool
+		wantWarning  bool
+	}{
+		{"all ints", `[1, 2, 3]`, types.JSONInt, false, false},
+		{"int and float", `[1, 2, 2.5]`, types.JSONFloat, false, false},
+		{"float first", `[0.5, 1]`, types.JSONFloat, false, false},
+		{"ints with null", `[1, null, 3]`, types.JSONInt, true, false},
+		{"strings with null", `[null, "a"]`, types.JSONString, true, false},
+		{"only nulls", `[null, null]`, types.JSONNull, false, false},
+		{"string and int", `[1, "a", 2]`, types.JSONString, false, true},
+		{"bool and int", `[true, 1]`, types.JSONInt, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var arr []interface{}
+			if err := json.Unmarshal([]byte(tt.input), &arr); err != nil {
+				t.Fatal(err)
+			}
+			p := NewParser(false, false)
+			got, nullable, _, err := p.inferArrayElementType(arr, "Root.values", types.PolicyWidening)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Type != tt.want || nullable != tt.wantNullable {
+				t.Errorf("inferArrayElementType(%s) = (%v, %v), want (%v, %v)",
+					tt.input, got.Type, nullable, tt.want, tt.wantNullable)
+			}
+			if gotWarning := len(p.Warnings()) > 0; gotWarning != tt.wantWarning {
+				t.Errorf("inferArrayElementType(%s) warnings = %v, want warning %v",
+					tt.input, p.Warnings(), tt.wantWarning)
+			}
+		})
+	}
+}
+
+func TestArrayPromotionPolicy(t *testing.T) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(`{"xs": [1, "a", true], "ys": [1, "b"]}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	ysHint := &hints.Hints{Fields: map[string]hints.Hint{"ys": {Promotion: "widening"}}}
+
+	tests := []struct {
+		policy    types.PromotionPolicy
+		xs        string
+		wantError bool
+	}{
+		{types.PolicyWidening, "string", false},
+		{types.PolicyVariant, "variant of bool, int, string", false},
+		{types.PolicyLastWins, "bool", false},
+		{types.PolicyError, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			p := NewParserWithOptions(false, false, Options{Promotion: tt.policy, Hints: ysHint})
+			structs, err := p.ParseValue(v, "Root")
+			if (err != nil) != tt.wantError {
+				t.Fatalf("err = %v, want error %v", err, tt.wantError)
+			}
+			if tt.wantError {
+				return
+			}
+			xs := findField(structs, "xs")
+			if got := (types.Promotion{Type: xs.ElemType, Variants: xs.Variants}).String(); got != tt.xs {
+				t.Errorf("xs elements = %s, want %s", got, tt.xs)
+			}
+			// the hint keeps ys on the widening policy
+			if ys := findField(structs, "ys"); ys.ElemType != types.JSONString || ys.Promotion != "widening" {
+				t.Errorf("ys = %v elements with policy %q, want string with widening", ys.ElemType, ys.Promotion)
+			}
+		})
+	}
+}
+
+func TestSingularItemNames(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		key        string
+		exceptions map[string]string
+		want       string
+	}{
+		{"plural key", `{"users": [{"id": 1}]}`, "users", nil, "User"},
+		{"ies plural", `{"categories": [{"id": 1}]}`, "categories", nil, "Category"},
+		{"not plural", `{"data": [{"id": 1}]}`, "data", nil, "DataItem"},
+		{"singular unclear", `{"sandwiches": [{"id": 1}]}`, "sandwiches", nil, "SandwichesItem"},
+		{"exception", `{"data": [{"id": 1}]}`, "data", map[string]string{"data": "record"}, "Record"},
+		{"collides with object", `{"user": {"name": "a"}, "users": [{"id": 1}]}`, "users", nil, "UsersItem"},
+		{"object claims name later", `{"a": {"users": [{"id": 1}]}, "b": {"user": {"name": "x"}}}`, "users", nil, "UsersItem"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatal(err)
+			}
+			p := NewParserWithOptions(false, false, Options{SingularNames: true, SingularExceptions: tt.exceptions})
+			structs, err := p.ParseValue(v, "Root")
+			if err != nil {
+				t.Fatal(err)
+			}
+			field := findField(structs, tt.key)
+			if field == nil || field.NestedType == nil {
+				t.Fatalf("no array-of-object field %q in result", tt.key)
+			}
+			if field.NestedType.Name != tt.want {
+				t.Errorf("item struct for %q = %q, want %q", tt.key, field.NestedType.Name, tt.want)
+			}
+		})
+	}
+}
+
+func findField(structs []*types.Struct, jsonName string) *types.Field {
+	for _, s := range structs {
+		for _, f := range s.Fields {
+			if f.JSONName == jsonName {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+func TestTaggedUnionDetection(t *testing.T) {
+	input := `{"events": [
+		{"type": "click", "x": 1},
+		{"type": "view", "page": "home"},
+		{"type": "click", "x": 2}
+	]}`
+	var v interface{}
+	if err := json.Unmarshal([]byte(input), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParserWithOptions(false, false, Options{DetectUnions: true})
+	structs, err := p.ParseValue(v, "Root")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	field := findField(structs, "events")
+	if field == nil || field.NestedType == nil || field.NestedType.Union == nil {
+		t.Fatalf("events is not a tagged union: %+v", field)
+	}
+	union := field.NestedType.Union
+	if union.Tag != "type" {
+		t.Errorf("union tag = %q, want %q", union.Tag, "type")
+	}
+	want := map[string]string{"click": "EventsItemClick", "view": "EventsItemView"}
+	if len(union.Variants) != len(want) {
+		t.Fatalf("got %d variants, want %d", len(union.Variants), len(want))
+	}
+	for _, variant := range union.Variants {
+		if variant.Type.Name != want[variant.Value] {
+			t.Errorf("variant %q = %q, want %q", variant.Value, variant.Type.Name, want[variant.Value])
+		}
+	}
+
+	// A single tag value is not a union
+	if tag := p.unionTag([]interface{}{
+		map[string]interface{}{"type": "click"},
+		map[string]interface{}{"type": "click"},
+	}); tag != "" {
+		t.Errorf("unionTag with one tag value = %q, want none", tag)
+	}
+
+	// Tag values that map to the same struct name get a numeric suffix
+	structs, err = p.ParseValue([]interface{}{
+		map[string]interface{}{"type": "a-b", "x": 1.0},
+		map[string]interface{}{"type": "a_b", "y": "s"},
+	}, "Root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, s := range structs {
+		if s.Union != nil {
+			for _, variant := range s.Union.Variants {
+				names = append(names, variant.Type.Name)
+			}
+		}
+	}
+	if len(names) != 2 || names[0] == names[1] {
+		t.Errorf("variant struct names = %v, want two distinct names", names)
+	}
+}
+
+func TestBase64Format(t *testing.T) {
+	tests := []struct {
+		input string
+		want  types.Format
+	}{
+		{"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg==", types.FormatBase64},
+		{"3q2+7wAAAAAAAAAAAAAAAA==", types.FormatBase64},
+		{"eyJhbGciOiJIUzI1NiJ9_-ab", types.FormatBase64URL},
+		{"AbCdEfGh12345678", types.FormatDefault},                 // identifier: no padding or symbol
+		{"iVBORw0KGgoAAAANSUhEUgAAAAEAAAAB", types.FormatDefault}, // unmarked data needs a hint
+		{"SGVsbG8sIFdvcmxkIQ==", types.FormatDefault},             // decodes to text
+		{"3q2+7wAAAAAAAAAAAAAAAA", types.FormatDefault},           // missing padding
+		{"short", types.FormatDefault},
+		{"HelloWorldHelloWorld", types.FormatDefault},                 // no digits or symbols
+		{"d41d8cd98f00b204e9800998ecf8427e", types.FormatDefault},     // hex digest
+		{"123e4567-e89b-12d3-a456-426614174000", types.FormatDefault}, // UUID
+		{"This is a sentence, not data", types.FormatDefault},         // spaces
+		{"SGVsbG8sIFdvcmxkIQ=", types.FormatDefault},                  // bad padding
+		{"ab+cd_efGH12ijklmn", types.FormatDefault},                   // mixed alphabets
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := base64Format(tt.input); got != tt.want {
+				t.Errorf("base64Format(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumericStringFormat(t *testing.T) {
+	tests := []struct {
+		input string
+		want  types.Format
+	}{
+		{"42", types.FormatIntString},
+		{"-7", types.FormatIntString},
+		{"0", types.FormatIntString},
+		{"0.75", types.FormatFloatString},
+		{"-19.99", types.FormatFloatString},
+		{"1e+21", types.FormatFloatString},
+		{"007", types.FormatDefault},                  // leading zeros
+		{"+5", types.FormatDefault},                   // sign prefix
+		{"1.50", types.FormatDefault},                 // trailing zero
+		{"1E5", types.FormatDefault},                  // exponent spelling
+		{"99999999999999999999", types.FormatDefault}, // beyond int64
+		{"NaN", types.FormatDefault},
+		{"12 apples", types.FormatDefault},
+		{"", types.FormatDefault},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := numericStringFormat(tt.input); got != tt.want {
+				t.Errorf("numericStringFormat(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	input := `{
+		"count": 3,
+		"count$json2cpp": {"type": "uint32", "name": "total"},
+		"big": 18446744073709551615,
+		"big$json2cpp": {"type": "uint64"},
+		"retries": 2,
+		"retries$json2cpp": {"default": 5},
+		"address": {
+			"$json2cpp": {"name": "PostalAddress", "optional": true},
+			"city": "Seoul"
+		}
+	}`
+	var v interface{}
+	if err := json.Unmarshal([]byte(input), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParser(false, false)
+	structs, err := p.ParseValue(v, "Root")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range structs {
+		for _, f := range s.Fields {
+			if strings.Contains(f.JSONName, AnnotationKey) {
+				t.Errorf("annotation %q became a field of %s", f.JSONName, s.Name)
+			}
+		}
+	}
+
+	count := findField(structs, "count")
+	if count == nil || count.Name != "total" || count.CppType != "uint32_t" {
+		t.Errorf("count = %+v, want member total of type uint32_t", count)
+	}
+	if big := findField(structs, "big"); big == nil || big.Type != types.JSONInt || big.CppType != "uint64_t" {
+		t.Errorf("big = %+v, want an integer of type uint64_t", big)
+	}
+	if len(p.Warnings()) > 0 {
+		t.Errorf("unexpected warnings: %v", p.Warnings())
+	}
+	if retries := findField(structs, "retries"); retries == nil || retries.Default != "5" {
+		t.Errorf("retries = %+v, want default 5", retries)
+	}
+	address := findField(structs, "address")
+	if address == nil || address.NestedType == nil || address.NestedType.Name != "PostalAddress" || !address.IsOptional {
+		t.Errorf("address = %+v, want optional PostalAddress", address)
+	}
+
+	tests := []struct {
+		name  string
+		input map[string]interface{}
+	}{
+		{"unknown directive", map[string]interface{}{"a": 1.0, "a$json2cpp": map[string]interface{}{"size": 4.0}}},
+		{"unknown type", map[string]interface{}{"a": 1.0, "a$json2cpp": map[string]interface{}{"type": "int128"}}},
+		{"type on object", map[string]interface{}{"$json2cpp": map[string]interface{}{"type": "uint32"}}},
+		{"array default", map[string]interface{}{"a": 1.0, "a$json2cpp": map[string]interface{}{"default": []interface{}{1.0}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewParser(false, false).ParseValue(tt.input, "Root"); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestScalarHintRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		wantCppType string
+		wantType    types.JSONType
+	}{
+		{"in range", `{"a": 100, "a$json2cpp": {"type": "int8"}}`, "int8_t", types.JSONInt},
+		{"above int8", `{"a": 300, "a$json2cpp": {"type": "int8"}}`, "", types.JSONInt},
+		{"negative uint", `{"a": -1, "a$json2cpp": {"type": "uint32"}}`, "", types.JSONInt},
+		{"beyond int64 as uint64", `{"a": 10000000000000000000, "a$json2cpp": {"type": "uint64"}}`, "uint64_t", types.JSONInt},
+		{"beyond int64 as int64", `{"a": 10000000000000000000, "a$json2cpp": {"type": "int64"}}`, "", types.JSONFloat},
+		{"beyond int64 as uint32", `{"a": 10000000000000000000, "a$json2cpp": {"type": "uint32"}}`, "", types.JSONFloat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatal(err)
+			}
+			p := NewParser(false, false)
+			structs, err := p.ParseValue(v, "Root")
+			if err != nil {
+				t.Fatal(err)
+			}
+			a := findField(structs, "a")
+			if a == nil || a.CppType != tt.wantCppType || a.Type != tt.wantType {
+				t.Fatalf("a = %+v, want %s of type %q", a, tt.wantType, tt.wantCppType)
+			}
+			if gotWarning := len(p.Warnings()) > 0; gotWarning != (tt.wantCppType == "") {
+				t.Errorf("warnings = %v, want a warning: %v", p.Warnings(), tt.wantCppType == "")
+			}
+		})
+	}
+}
+
+func TestHintDefaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string // "" when the default is ignored with a warning
+	}{
+		{"int", `{"a": 1, "a$json2cpp": {"default": 5}}`, "5"},
+		{"numeric string on int", `{"a": 1, "a$json2cpp": {"default": "5"}}`, `"5"`},
+		{"string on int", `{"a": 1, "a$json2cpp": {"default": "abc"}}`, ""},
+		{"fraction on int", `{"a": 1, "a$json2cpp": {"default": 1.5}}`, ""},
+		{"in int8 range", `{"a": 1, "a$json2cpp": {"type": "int8", "default": -100}}`, "-100"},
+		{"beyond int8", `{"a": 1, "a$json2cpp": {"type": "int8", "default": 1000}}`, ""},
+		{"negative uint", `{"a": 1, "a$json2cpp": {"type": "uint16", "default": -1}}`, ""},
+		{"number on float", `{"a": 1.5, "a$json2cpp": {"default": 2}}`, "2"},
+		{"bool on string", `{"a": "x", "a$json2cpp": {"default": true}}`, ""},
+		{"bool", `{"a": false, "a$json2cpp": {"default": true}}`, "true"},
+		{"number on decimal", `{"a": 1.5, "a$json2cpp": {"type": "decimal", "default": "2.25"}}`, `"2.25"`},
+		{"base64", `{"a": "aGk=", "a$json2cpp": {"type": "base64", "default": "aGk="}}`, ""},
+		{"null keeps it for the merge", `{"a": null, "a$json2cpp": {"default": 3}}`, "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v interface{}
+			if err := json.Unmarshal([]byte(tt.input), &v); err != nil {
+				t.Fatal(err)
+			}
+			p := NewParser(false, false)
+			structs, err := p.ParseValue(v, "Root")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a := findField(structs, "a"); a == nil || a.Default != tt.want {
+				t.Errorf("a = %+v, want default %q", a, tt.want)
+			}
+			if gotWarning := len(p.Warnings()) > 0; gotWarning != (tt.want == "") {
+				t.Errorf("warnings = %v, want a warning: %v", p.Warnings(), tt.want == "")
+			}
+		})
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	var v interface{}
+	if err := json.Unmarshal([]byte(`{"user": {"name": "kim", "tags": ["a", "b"]}, "items": [{"id": 1}]}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	structs, err := NewParser(false, false).ParseValue(v, "Root")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key      string
+		pointer  string
+		examples []string
+	}{
+		{"name", "/user/name", []string{`"kim"`}},
+		{"tags", "/user/tags", []string{`["a","b"]`}},
+		{"id", "/items/0/id", []string{"1"}},
+		{"items", "/items", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			f := findField(structs, tt.key)
+			if f == nil {
+				t.Fatal("field not found")
+			}
+			if len(f.Pointers) != 1 || f.Pointers[0] != tt.pointer {
+				t.Errorf("pointers = %v, want [%s]", f.Pointers, tt.pointer)
+			}
+			if strings.Join(f.Examples, " ") != strings.Join(tt.examples, " ") {
+				t.Errorf("examples = %v, want %v", f.Examples, tt.examples)
+			}
+		})
+	}
+}
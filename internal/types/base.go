@@ -0,0 +1,195 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MinBaseFields is the smallest field set worth extracting into a base struct.
+const MinBaseFields = 2
+
+// ExtractBaseStructs finds sets of at least MinBaseFields identical fields
+// (same JSON key, type and optionality) shared by minStructs or more structs,
+// moves each set into a new base struct and makes the structs inherit from
+// it. Sets are picked greedily, largest (fields x structs) first, and every
+// struct gets at most one base. The new structs are appended to info.Structs.
+func ExtractBaseStructs(info *TypeInfo, minStructs int) []*Struct {
+	if minStructs < 2 {
+		minStructs = 2
+	}
+
+	var bases []*Struct
+	for {
+		candidates := baseCandidates(info.Structs)
+		set, members := bestFieldSet(candidates, minStructs)
+		if set == nil {
+			break
+		}
+
+		base := &Struct{
+			Name: uniqueStructName(info.Structs, "CommonBase"),
+		}
+		for _, sig := range set {
+			shared := members[0].fieldBySignature(sig)
+			field := *shared
+			field.Trace = append([]string{}, shared.Trace...)
+			field.Sources = append([]string{}, shared.Sources...)
+			field.Pointers = append([]string{}, shared.Pointers...)
+			field.Examples = append([]string{}, shared.Examples...)
+			for _, m := range members[1:] {
+				field.AddProvenance(m.fieldBySignature(sig))
+			}
+			field.AddTrace("moved to base %s (shared by %d structs)", base.Name, len(members))
+			base.Fields = append(base.Fields, &field)
+		}
+
+		for _, m := range members {
+			remaining := make([]*Field, 0, len(m.Fields))
+			for _, f := range m.Fields {
+				if !containsString(set, fieldSignature(f)) {
+					remaining = append(remaining, f)
+				}
+			}
+			m.Fields = remaining
+			m.Base = base
+		}
+
+		info.Structs = append(info.Structs, base)
+		bases = append(bases, base)
+	}
+
+	return bases
+}
+
+// fieldSignature identifies fields that can be shared through a base struct.
+func fieldSignature(f *Field) string {
+	nested := ""
+	if f.NestedType != nil {
+		nested = f.NestedType.Name
+	}
+	return fmt.Sprintf("%s|%s|%s|%s|%v|%v|%v|%v|%s|%d|%s", f.JSONName, f.Type, f.ElemType, nested, f.ElemNullable, f.IsOptional, f.Nullable, f.Variants, f.Default, f.Format, f.CppType)
+}
+
+func (s *Struct) fieldBySignature(sig string) *Field {
+	for _, f := range s.Fields {
+		if fieldSignature(f) == sig {
+			return f
+		}
+	}
+	return nil
+}
+
+type baseCandidate struct {
+	s    *Struct
+	sigs []string // sorted signatures of the fields shared with another struct
+}
+
+// baseCandidates lists the structs that may still get a base, each with the
+// signatures of its fields that occur in at least one other struct.
+func baseCandidates(structs []*Struct) []baseCandidate {
+	counts := make(map[string]int)
+	for _, s := range structs {
+		if s.Base != nil || s.Union != nil {
+			continue
+		}
+		for _, f := range s.Fields {
+			counts[fieldSignature(f)]++
+		}
+	}
+
+	candidates := make([]baseCandidate, 0)
+	for _, s := range structs {
+		if s.Base != nil || s.Union != nil {
+			continue
+		}
+		sigs := make([]string, 0)
+		for _, f := range s.Fields {
+			if sig := fieldSignature(f); counts[sig] > 1 {
+				sigs = append(sigs, sig)
+			}
+		}
+		if len(sigs) >= MinBaseFields {
+			sort.Strings(sigs)
+			candidates = append(candidates, baseCandidate{s: s, sigs: sigs})
+		}
+	}
+	return candidates
+}
+
+// bestFieldSet tries the shared fields of every pair of candidates and returns
+// the field set with the highest fields x structs score that at least
+// minStructs candidates contain, along with those candidates.
+func bestFieldSet(candidates []baseCandidate, minStructs int) ([]string, []*Struct) {
+	var bestSet []string
+	var bestMembers []*Struct
+	bestScore := 0
+
+	for i := range candidates {
+		for j := i + 1; j < len(candidates); j++ {
+			set := intersectSorted(candidates[i].sigs, candidates[j].sigs)
+			if len(set) < MinBaseFields {
+				continue
+			}
+			var members []*Struct
+			for _, c := range candidates {
+				if containsAll(c.sigs, set) {
+					members = append(members, c.s)
+				}
+			}
+			if len(members) < minStructs {
+				continue
+			}
+			score := len(set) * len(members)
+			if score > bestScore || (score == bestScore && strings.Join(set, ",") < strings.Join(bestSet, ",")) {
+				bestScore, bestSet, bestMembers = score, set, members
+			}
+		}
+	}
+
+	return bestSet, bestMembers
+}
+
+func intersectSorted(a, b []string) []string {
+	result := make([]string, 0)
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return result
+}
+
+func containsAll(sorted, subset []string) bool {
+	return len(intersectSorted(sorted, subset)) == len(subset)
+}
+
+func containsString(list []string, item string) bool {
+	for _, s := range list {
+		if s == item {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueStructName returns name, or name with a numeric suffix when a struct
+// of that name already exists.
+func uniqueStructName(structs []*Struct, name string) string {
+	taken := make(map[string]bool)
+	for _, s := range structs {
+		taken[s.Name] = true
+	}
+	candidate := name
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	return candidate
+}
@@ -0,0 +1,58 @@
+package types
+
+import "testing"
+
+func TestExtractBaseStructs(t *testing.T) {
+	audit := func(name string, extra string) *Struct {
+		return &Struct{Name: name, Fields: []*Field{
+			{Name: "id", JSONName: "id", Type: JSONInt},
+			{Name: "created_at", JSONName: "created_at", Type: JSONString},
+			{Name: extra, JSONName: extra, Type: JSONString},
+		}}
+	}
+	user, order, item := audit("User", "name"), audit("Order", "total"), audit("Item", "sku")
+	other := &Struct{Name: "Other", Fields: []*Field{
+		{Name: "id", JSONName: "id", Type: JSONString}, // different type: not shared
+		{Name: "created_at", JSONName: "created_at", Type: JSONString},
+	}}
+	info := &TypeInfo{Structs: []*Struct{user, order, item, other}}
+
+	bases := ExtractBaseStructs(info, 3)
+	if len(bases) != 1 {
+		t.Fatalf("got %d base structs, want 1", len(bases))
+	}
+	base := bases[0]
+	if base.Name != "CommonBase" || len(base.Fields) != 2 {
+		t.Errorf("base = %s with %d fields, want CommonBase with 2", base.Name, len(base.Fields))
+	}
+	for _, s := range []*Struct{user, order, item} {
+		if s.Base != base {
+			t.Errorf("%s does not inherit from %s", s.Name, base.Name)
+		}
+		if len(s.Fields) != 1 {
+			t.Errorf("%s keeps %d fields, want 1", s.Name, len(s.Fields))
+		}
+	}
+	if other.Base != nil || len(other.Fields) != 2 {
+		t.Errorf("Other was changed: base %v, %d fields", other.Base, len(other.Fields))
+	}
+	if len(info.Structs) != 5 {
+		t.Errorf("info has %d structs, want 5", len(info.Structs))
+	}
+
+	// A threshold above the number of sharing structs extracts nothing
+	if bases := ExtractBaseStructs(&TypeInfo{Structs: []*Struct{audit("A", "a"), audit("B", "b")}}, 3); len(bases) != 0 {
+		t.Errorf("got %d base structs below threshold, want 0", len(bases))
+	}
+
+	// Members that differ only in format or hinted C++ type are not shared
+	price := func(name string, format Format, cppType string) *Struct {
+		return &Struct{Name: name, Fields: []*Field{
+			{Name: "price", JSONName: "price", Type: JSONFloat, Format: format, CppType: cppType},
+		}}
+	}
+	mixed := &TypeInfo{Structs: []*Struct{price("A", FormatDefault, ""), price("B", FormatDecimal, ""), price("C", FormatDefault, "float")}}
+	if bases := ExtractBaseStructs(mixed, 2); len(bases) != 0 {
+		t.Errorf("got %d base structs for members of different formats and types, want 0", len(bases))
+	}
+}
@@ -0,0 +1,128 @@
+package types
+
+import "sort"
+
+// CombineTypes adds the structs of another document to structs without
+// merging them. A struct with the same name and shape as an existing one is
+// shared, and one whose name is taken by a different struct is renamed to
+// prefix+Name (numbered if that is taken too). References within types2 are
+// rewired to the shared structs. It returns the combined list and the
+// renames, old name -> new name.
+func CombineTypes(structs, types2 []*Struct, prefix string) ([]*Struct, map[string]string) {
+	c := &combiner{
+		result:   append([]*Struct{}, structs...),
+		byName:   make(map[string]*Struct),
+		resolved: make(map[*Struct]*Struct),
+		renames:  make(map[string]string),
+		prefix:   prefix,
+	}
+	for _, s := range structs {
+		c.byName[s.Name] = s
+	}
+	for _, s := range types2 {
+		c.resolve(s)
+	}
+	return c.result, c.renames
+}
+
+type combiner struct {
+	result   []*Struct
+	byName   map[string]*Struct
+	resolved map[*Struct]*Struct // struct of types2 -> struct used in result
+	renames  map[string]string
+	prefix   string
+}
+
+// resolve decides what s becomes in the result, after resolving the structs
+// it refers to so that shapes are compared with final names.
+func (c *combiner) resolve(s *Struct) *Struct {
+	if r, ok := c.resolved[s]; ok {
+		return r
+	}
+	c.resolved[s] = s // guards against reference cycles
+
+	for _, f := range s.Fields {
+		if f.NestedType != nil {
+			f.NestedType = c.resolve(f.NestedType)
+		}
+	}
+	if s.Union != nil {
+		for _, v := range s.Union.Variants {
+			v.Type = c.resolve(v.Type)
+		}
+	}
+	if s.Base != nil {
+		s.Base = c.resolve(s.Base)
+	}
+
+	if existing, taken := c.byName[s.Name]; taken {
+		if sameShape(existing, s) {
+			shareProvenance(existing, s)
+			c.resolved[s] = existing
+			return existing
+		}
+		name := uniqueStructName(c.result, c.prefix+s.Name)
+		c.renames[s.Name] = name
+		s.Name = name
+	}
+	c.byName[s.Name] = s
+	c.result = append(c.result, s)
+	return s
+}
+
+// sameShape reports whether two structs would generate the same C++ type.
+func sameShape(a, b *Struct) bool {
+	if len(a.Fields) != len(b.Fields) || a.Base != b.Base || (a.Union == nil) != (b.Union == nil) {
+		return false
+	}
+	if !equalStrings(shapeSignatures(a), shapeSignatures(b)) {
+		return false
+	}
+	if a.Union == nil {
+		return true
+	}
+	if a.Union.Tag != b.Union.Tag || len(a.Union.Variants) != len(b.Union.Variants) {
+		return false
+	}
+	for _, v := range a.Union.Variants {
+		other := b.Union.Variant(v.Value)
+		if other == nil || other.Type != v.Type {
+			return false
+		}
+	}
+	return true
+}
+
+// shapeSignatures returns the sorted signatures of the members of s.
+func shapeSignatures(s *Struct) []string {
+	sigs := make([]string, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		sigs = append(sigs, fieldSignature(f)+"|"+f.Name)
+	}
+	sort.Strings(sigs)
+	return sigs
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// shareProvenance records the input files, pointers and sample values of s
+// on the fields of the struct it is shared with.
+func shareProvenance(existing, s *Struct) {
+	for _, f := range existing.Fields {
+		for _, f2 := range s.Fields {
+			if f2.JSONName == f.JSONName {
+				f.AddProvenance(f2)
+			}
+		}
+	}
+}
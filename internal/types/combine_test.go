@@ -0,0 +1,42 @@
+package types
+
+import "testing"
+
+func TestCombineTypes(t *testing.T) {
+	address := func() *Struct {
+		return &Struct{Name: "Address", Fields: []*Field{
+			{Name: "city", JSONName: "city", Type: JSONString},
+		}}
+	}
+	item := func(extra string) *Struct {
+		return &Struct{Name: "TagsItem", Fields: []*Field{
+			{Name: extra, JSONName: extra, Type: JSONString},
+		}}
+	}
+	root := func(name string, addr, tags *Struct) *Struct {
+		return &Struct{Name: name, Fields: []*Field{
+			{Name: "address", JSONName: "address", Type: JSONObject, NestedType: addr},
+			{Name: "tags", JSONName: "tags", Type: JSONArray, NestedType: tags},
+		}}
+	}
+
+	userAddress, userTags := address(), item("k")
+	user := root("User", userAddress, userTags)
+	orderAddress, orderTags := address(), item("v")
+	order := root("Order", orderAddress, orderTags)
+
+	combined, renames := CombineTypes([]*Struct{userAddress, userTags, user}, []*Struct{orderAddress, orderTags, order}, "Order")
+
+	if len(combined) != 5 {
+		t.Fatalf("got %d structs, want 5", len(combined))
+	}
+	if order.Fields[0].NestedType != userAddress {
+		t.Error("identical Address was not shared")
+	}
+	if orderTags.Name != "OrderTagsItem" || renames["TagsItem"] != "OrderTagsItem" {
+		t.Errorf("differing TagsItem named %q, renames %v; want OrderTagsItem", orderTags.Name, renames)
+	}
+	if userTags.Name != "TagsItem" {
+		t.Errorf("existing TagsItem renamed to %q", userTags.Name)
+	}
+}
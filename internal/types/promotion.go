@@ -0,0 +1,194 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PromotionPolicy decides what a value becomes when its samples disagree on
+// kinds that do not join on the inference lattice (see JoinTypes), such as
+// int and string or a string and an object. It is consulted by the merge of
+// samples and by the element inference of mixed arrays.
+type PromotionPolicy interface {
+	// Promote returns the kind of a value seen as earlier and then as later.
+	// Returning earlier or later keeps the shape of that sample; JSONVariant
+	// holds the scalar kinds of both.
+	Promote(earlier, later JSONType) (JSONType, error)
+	// String returns the name the policy is selected by.
+	String() string
+}
+
+var (
+	// PolicyWidening promotes disagreeing scalars along the fixed ranking
+	// string > float > int > bool and keeps the earlier shape when an object
+	// or array is involved. It is the default.
+	PolicyWidening PromotionPolicy = widening{}
+	// PolicyVariant keeps every scalar kind in a std::variant. An object or
+	// array keeps the earlier shape, as with PolicyWidening.
+	PolicyVariant PromotionPolicy = variantPolicy{}
+	// PolicyError rejects any disagreement.
+	PolicyError PromotionPolicy = errorPolicy{}
+	// PolicyLastWins takes the kind and shape of the later sample.
+	PolicyLastWins PromotionPolicy = lastWins{}
+)
+
+// PromotionPolicies are the policies selectable by name.
+var PromotionPolicies = map[string]PromotionPolicy{
+	PolicyWidening.String(): PolicyWidening,
+	PolicyVariant.String():  PolicyVariant,
+	PolicyError.String():    PolicyError,
+	PolicyLastWins.String(): PolicyLastWins,
+}
+
+// PolicyByName returns the promotion policy with the given name; "" selects
+// PolicyWidening.
+func PolicyByName(name string) (PromotionPolicy, error) {
+	if name == "" {
+		return PolicyWidening, nil
+	}
+	if policy, ok := PromotionPolicies[name]; ok {
+		return policy, nil
+	}
+	names := make([]string, 0, len(PromotionPolicies))
+	for n := range PromotionPolicies {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown promotion policy %q (choose: %s)", name, strings.Join(names, ", "))
+}
+
+type widening struct{}
+
+func (widening) String() string { return "widening" }
+
+func (widening) Promote(earlier, later JSONType) (JSONType, error) {
+	switch {
+	case !isScalar(earlier) || !isScalar(later):
+		return earlier, nil
+	case earlier == JSONVariant || later == JSONVariant:
+		return JSONVariant, nil
+	default:
+		return promoteType(earlier, later), nil
+	}
+}
+
+type variantPolicy struct{}
+
+func (variantPolicy) String() string { return "variant" }
+
+func (variantPolicy) Promote(earlier, later JSONType) (JSONType, error) {
+	if !isScalar(earlier) || !isScalar(later) {
+		return earlier, nil
+	}
+	return JSONVariant, nil
+}
+
+type errorPolicy struct{}
+
+func (errorPolicy) String() string { return "error" }
+
+func (errorPolicy) Promote(earlier, later JSONType) (JSONType, error) {
+	return earlier, fmt.Errorf("%s and %s do not join", earlier, later)
+}
+
+type lastWins struct{}
+
+func (lastWins) String() string { return "last-wins" }
+
+func (lastWins) Promote(earlier, later JSONType) (JSONType, error) {
+	return later, nil
+}
+
+// Promotion is the outcome of reconciling two kinds.
+type Promotion struct {
+	Type     JSONType
+	Variants []JSONType // scalar kinds of a JSONVariant result
+	// Joined reports that the kinds joined on the lattice, so the policy
+	// was not consulted.
+	Joined bool
+}
+
+// String describes the kind, listing the kinds of a variant.
+func (p Promotion) String() string {
+	if p.Type == JSONVariant {
+		return "variant of " + VariantKindNames(p.Variants)
+	}
+	return p.Type.String()
+}
+
+// Promote reconciles the kind t1 seen so far with a newly seen kind t2; v1
+// and v2 are the kinds held when t1 or t2 is JSONVariant. Kinds that join on
+// the lattice (including a variant and kinds it already holds) are joined,
+// anything else is left to policy.
+func Promote(policy PromotionPolicy, t1 JSONType, v1 []JSONType, t2 JSONType, v2 []JSONType) (Promotion, error) {
+	k1, k2 := variantKinds(t1, v1), variantKinds(t2, v2)
+	joined, ok := JoinTypes(t1, t2)
+	switch {
+	case ok:
+	case t1 == JSONVariant && isScalar(t2) && holdsKinds(k1, k2):
+		joined, ok = JSONVariant, true
+	case t2 == JSONVariant && isScalar(t1) && holdsKinds(k2, k1):
+		joined, ok = JSONVariant, true
+	default:
+		if policy == nil {
+			policy = PolicyWidening
+		}
+		var err error
+		if joined, err = policy.Promote(t1, t2); err != nil {
+			return Promotion{}, err
+		}
+	}
+
+	result := Promotion{Type: joined, Joined: ok}
+	if joined == JSONVariant {
+		result.Variants = unionKinds(k1, k2)
+	}
+	return result, nil
+}
+
+// variantKinds returns the scalar kinds a value of kind t can hold.
+func variantKinds(t JSONType, variants []JSONType) []JSONType {
+	switch {
+	case t == JSONVariant:
+		return variants
+	case t == JSONNull || !isScalar(t):
+		return nil
+	default:
+		return []JSONType{t}
+	}
+}
+
+// holdsKinds reports whether b adds no kind to a, other than widening int to
+// float.
+func holdsKinds(a, b []JSONType) bool {
+	return len(unionKinds(a, b)) == len(unionKinds(a, nil))
+}
+
+// unionKinds returns the kinds of a and b in lattice order, with int folded
+// into float when both occur.
+func unionKinds(a, b []JSONType) []JSONType {
+	seen := make(map[JSONType]bool)
+	for _, t := range append(append([]JSONType{}, a...), b...) {
+		seen[t] = true
+	}
+	if seen[JSONFloat] {
+		delete(seen, JSONInt)
+	}
+	kinds := make([]JSONType, 0, len(seen))
+	for _, t := range []JSONType{JSONBool, JSONInt, JSONFloat, JSONString} {
+		if seen[t] {
+			kinds = append(kinds, t)
+		}
+	}
+	return kinds
+}
+
+// VariantKindNames renders the kinds held by a variant, e.g. "int, string".
+func VariantKindNames(kinds []JSONType) string {
+	names := make([]string, 0, len(kinds))
+	for _, t := range kinds {
+		names = append(names, t.String())
+	}
+	return strings.Join(names, ", ")
+}
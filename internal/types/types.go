@@ -1,7 +1,9 @@
 package types
 
 import (
+	"fmt"
 	"json2cpp/internal/nameutil"
+	"strings"
 )
 
 type JSONType int
@@ -14,6 +16,7 @@ const (
 	JSONString
 	JSONArray
 	JSONObject
+	JSONVariant // one of several scalar kinds, see Field.Variants
 )
 
 func (t JSONType) String() string {
@@ -32,6 +35,8 @@ func (t JSONType) String() string {
 		return "array"
 	case JSONObject:
 		return "object"
+	case JSONVariant:
+		return "variant"
 	default:
 		return "unknown"
 	}
@@ -53,6 +58,40 @@ func (t JSONType) ToCppType() string {
 		return "std::vector"
 	case JSONObject:
 		return "struct"
+	case JSONVariant:
+		return "std::variant"
+	default:
+		return "unknown"
+	}
+}
+
+// Format refines how a field's JSON value is represented in C++ when the plain
+// mapping of its JSONType is not precise enough.
+type Format int
+
+const (
+	FormatDefault     Format = iota
+	FormatDecimal            // number as exact fixed-point Decimal
+	FormatBase64             // string as bytes, standard base64 alphabet
+	FormatBase64URL          // string as bytes, URL-safe base64 alphabet
+	FormatIntString          // string holding an integer, as int64_t
+	FormatFloatString        // string holding a floating point number, as double
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatDefault:
+		return "default"
+	case FormatDecimal:
+		return "decimal"
+	case FormatBase64:
+		return "base64"
+	case FormatBase64URL:
+		return "base64url"
+	case FormatIntString:
+		return "int-string"
+	case FormatFloatString:
+		return "float-string"
 	default:
 		return "unknown"
 	}
@@ -64,12 +103,64 @@ type Field struct {
 	Type       JSONType
 	NestedType *Struct  // for object/array (for JSONObject or array of objects)
 	ElemType   JSONType // for arrays: element type when not an object
-	IsOptional bool
+	// ElemNullable is set when a primitive array also contained null elements
+	ElemNullable bool
+	IsOptional   bool
+	Nullable     bool   // some sample had null for the field, as opposed to leaving it out
+	Format       Format // representation override for primitive fields
+	CppType      string // explicit scalar C++ type (e.g. "uint32_t"), "" = inferred
+	// Variants lists the scalar kinds of a JSONVariant value or of JSONVariant
+	// array elements, in lattice order
+	Variants  []JSONType
+	Promotion string // promotion policy for this field (see PolicyByName), "" = the default
+	Default   string // JSON text of the member's default value from a hint, "" = none
+
+	// Inference trace, reported by `json2cpp explain`
+	Path     string   // JSON Pointer of the first occurrence
+	Sources  []string // input files that contained this field
+	Pointers []string // JSON Pointers of the field in those files
+	Examples []string // distinct sample values as JSON text, at most MaxExamples
+	Trace    []string // renames and promotions applied, in order
 }
 
+// DescribeJSONType renders the inferred JSON kind of a field, including the
+// element kind for arrays.
+func DescribeJSONType(f *Field) string {
+	if f.Type != JSONArray {
+		return Promotion{Type: f.Type, Variants: f.Variants}.String()
+	}
+	if f.NestedType != nil {
+		return "array of object"
+	}
+	elem := Promotion{Type: f.ElemType, Variants: f.Variants}.String()
+	if f.ElemNullable {
+		elem = "nullable " + elem
+	}
+	return "array of " + elem
+}
+
+// MaxExamples caps the sample values recorded per field.
+const MaxExamples = 3
+
 type Struct struct {
 	Name   string
 	Fields []*Field
+	Path   string  // JSON Pointer of the sampled object
+	Union  *Union  // non-nil for a tagged union wrapper (which has no fields)
+	Base   *Struct // base struct holding fields shared with other structs
+}
+
+// Union describes objects whose shape depends on a string tag field: the tag
+// value selects one of the variant structs.
+type Union struct {
+	Tag      string // JSON key of the tag field
+	Variants []*Variant
+}
+
+// Variant is one alternative of a tagged union.
+type Variant struct {
+	Value string  // tag value selecting this variant
+	Type  *Struct // struct for objects with this tag value (includes the tag field)
 }
 
 type TypeInfo struct {
@@ -89,55 +180,527 @@ func (s *Struct) HasField(name string) bool {
 	return s.GetField(name) != nil
 }
 
+// AddTrace records an inference step for `json2cpp explain`.
+func (f *Field) AddTrace(format string, args ...interface{}) {
+	f.Trace = append(f.Trace, fmt.Sprintf(format, args...))
+}
+
+// AddExample records a sample value unless it was seen before or the field
+// already has MaxExamples values.
+func (f *Field) AddExample(text string) {
+	if len(f.Examples) < MaxExamples {
+		f.Examples = appendUnique(f.Examples, text)
+	}
+}
+
+// AddProvenance records the files, pointers and sample values of other, the
+// same field seen in another sample.
+func (f *Field) AddProvenance(other *Field) {
+	f.Sources = appendUnique(f.Sources, other.Sources...)
+	f.Pointers = appendUnique(f.Pointers, other.Pointers...)
+	for _, e := range other.Examples {
+		f.AddExample(e)
+	}
+}
+
+func fromSources(sources []string) string {
+	if len(sources) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(sources, ", ") + ")"
+}
+
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// MergeTypes merges the structs of another sample into types1 and returns
+// the combined list. Structs are matched by name and, recursively, through
+// the object and array item fields they are reached by, so nested structs
+// gain the keys of every sample. The structs of types1 are updated in place
+// and every reference in the result points at a struct of the result.
 func MergeTypes(types1, types2 []*Struct) []*Struct {
-	result := make([]*Struct, 0)
-	structMap := make(map[string]*Struct)
+	result, _ := MergeTypesWithConflicts(types1, types2)
+	return result
+}
+
+// Conflict is a field whose samples disagree on its kind or representation,
+// and how the merge resolved it. A field missing from some samples is not a
+// conflict; it becomes optional.
+type Conflict struct {
+	Struct     string
+	Field      string // JSON key
+	Observed   string // the disagreeing kinds and the files they came from
+	Resolution string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s.%s: %s -> %s", c.Struct, c.Field, c.Observed, c.Resolution)
+}
+
+// MergeTypesWithConflicts is MergeTypes that also returns every conflict
+// between the samples, in the order they were found.
+func MergeTypesWithConflicts(types1, types2 []*Struct) ([]*Struct, []Conflict) {
+	result, conflicts, _ := MergeTypesWithPolicy(types1, types2, nil)
+	return result, conflicts
+}
 
-	// 첫 번째 타입 집합 추가
+// MergeTypesWithPolicy is MergeTypesWithConflicts with the promotion policy
+// for kinds that do not join (nil = PolicyWidening); a field naming its own
+// policy in Field.Promotion uses that instead. It fails when a policy rejects
+// a disagreement, after merging the rest; the rejected field keeps its
+// earlier kind and is reported as a conflict.
+func MergeTypesWithPolicy(types1, types2 []*Struct, policy PromotionPolicy) ([]*Struct, []Conflict, error) {
+	if policy == nil {
+		policy = PolicyWidening
+	}
+	m := &merger{
+		into:   make(map[*Struct]*Struct),
+		byName: make(map[string]*Struct),
+		policy: policy,
+	}
+	result := append([]*Struct{}, types1...)
 	for _, s := range types1 {
-		structMap[s.Name] = s
-		result = append(result, &Struct{
-			Name:   s.Name,
-			Fields: append([]*Field{}, s.Fields...),
-		})
+		m.byName[s.Name] = s
 	}
 
-	// 두 번째 타입 집합 병합
+	// 같은 이름의 struct가 있으면 필드 병합 (nested struct도 재귀적으로)
 	for _, s2 := range types2 {
-		if s1, exists := structMap[s2.Name]; exists {
-			// 같은 이름의 struct가 있으면 필드 병합
-			mergeStructFields(s1, s2)
-		} else {
-			// 새로운 struct 추가
-			result = append(result, &Struct{
-				Name:   s2.Name,
-				Fields: append([]*Field{}, s2.Fields...),
-			})
+		if s1, exists := m.byName[s2.Name]; exists {
+			m.mergeStruct(s1, s2)
 		}
 	}
 
-	return result
+	// 병합되지 않은 struct는 새로 추가 (같은 sample 안의 같은 이름 struct는 병합)
+	roots := rootStructs(types2)
+	for _, s2 := range types2 {
+		if _, merged := m.into[s2]; merged {
+			continue
+		}
+		if s1, exists := m.byName[s2.Name]; exists {
+			m.mergeStruct(s1, s2)
+			continue
+		}
+		m.into[s2] = s2
+		m.byName[s2.Name] = s2
+		result = append(result, s2)
+	}
+
+	for _, s := range result {
+		m.rewire(s)
+	}
+
+	// 충돌로 버려진 필드에서만 쓰이던 struct는 제거
+	seeds := append([]*Struct{}, types1...)
+	for _, r := range roots {
+		seeds = append(seeds, m.into[r])
+	}
+	used := reachableStructs(seeds)
+	kept := result[:0]
+	for _, s := range result {
+		if used[s] {
+			kept = append(kept, s)
+		}
+	}
+	return kept, m.conflicts, m.err
+}
+
+// rootStructs returns the structs that no other struct of the list refers to.
+func rootStructs(structs []*Struct) []*Struct {
+	referenced := make(map[*Struct]bool)
+	for _, s := range structs {
+		for _, t := range s.references() {
+			referenced[t] = true
+		}
+	}
+	roots := make([]*Struct, 0)
+	for _, s := range structs {
+		if !referenced[s] {
+			roots = append(roots, s)
+		}
+	}
+	return roots
 }
 
-func mergeStructFields(s1, s2 *Struct) {
+// reachableStructs returns the given structs and every struct they refer to,
+// directly or indirectly.
+func reachableStructs(seeds []*Struct) map[*Struct]bool {
+	seen := make(map[*Struct]bool)
+	var visit func(s *Struct)
+	visit = func(s *Struct) {
+		if s == nil || seen[s] {
+			return
+		}
+		seen[s] = true
+		for _, t := range s.references() {
+			visit(t)
+		}
+	}
+	for _, s := range seeds {
+		visit(s)
+	}
+	return seen
+}
+
+// references returns the structs s refers to through fields, union variants
+// and its base.
+func (s *Struct) references() []*Struct {
+	refs := make([]*Struct, 0)
+	for _, f := range s.Fields {
+		if f.NestedType != nil {
+			refs = append(refs, f.NestedType)
+		}
+	}
+	if s.Union != nil {
+		for _, v := range s.Union.Variants {
+			refs = append(refs, v.Type)
+		}
+	}
+	if s.Base != nil {
+		refs = append(refs, s.Base)
+	}
+	return refs
+}
+
+type merger struct {
+	into      map[*Struct]*Struct // struct of types2 -> struct of the result
+	byName    map[string]*Struct
+	policy    PromotionPolicy
+	conflicts []Conflict
+	err       error // first disagreement rejected by a policy
+}
+
+// mergeStruct merges s2 into s1, descending into the nested structs of the
+// fields they share.
+func (m *merger) mergeStruct(s1, s2 *Struct) {
+	if s1 == s2 {
+		return
+	}
+	if _, merged := m.into[s2]; merged {
+		return
+	}
+	m.into[s2] = s1
+	m.mergeStructFields(s1, s2)
+	m.mergeUnionVariants(s1, s2)
+}
+
+func (m *merger) mergeStructFields(s1, s2 *Struct) {
 	fieldMap := make(map[string]*Field)
 	for _, f := range s1.Fields {
 		fieldMap[f.JSONName] = f
 	}
 
+	var sources2 []string
 	for _, f2 := range s2.Fields {
+		sources2 = appendUnique(sources2, f2.Sources...)
+	}
+
+	seen := make(map[string]bool)
+	for _, f2 := range s2.Fields {
+		seen[f2.JSONName] = true
 		if f1, exists := fieldMap[f2.JSONName]; exists {
 			// 같은 필드가 있으면 타입 승격
-			f1.Type = promoteType(f1.Type, f2.Type)
+			observed := fmt.Sprintf("%s%s vs %s%s", describeField(f1), fromSources(f1.Sources), describeField(f2), fromSources(f2.Sources))
+			wasNull, isNull := f1.Type == JSONNull, f2.Type == JSONNull
+			m.mergeFieldType(s1, f1, f2, observed)
+			switch {
+			case wasNull:
+				// a null sample has no representation: take the other one
+				f1.Format = f2.Format
+				f1.CppType = f2.CppType
+			case isNull:
+			case f1.Format != f2.Format:
+				merged := mergeFormat(f1.Format, f2.Format)
+				f1.AddTrace("format: %s + %s -> %s%s", f1.Format, f2.Format, merged, fromSources(f2.Sources))
+				m.addConflict(s1, f1, observed, "format "+merged.String())
+				f1.Format = merged
+			}
+			if f1.CppType == "" && f2.CppType != "" {
+				f1.CppType = f2.CppType
+			}
 			if f1.IsOptional || f2.IsOptional {
 				f1.IsOptional = true
 			}
+			f1.Nullable = f1.Nullable || f2.Nullable
+			if f1.Default == "" {
+				f1.Default = f2.Default
+			}
+			f1.AddProvenance(f2)
 		} else {
 			// 새로운 필드는 optional로 추가
+			if !f2.IsOptional {
+				f2.AddTrace("optional: missing from an earlier sample")
+			}
 			f2.IsOptional = true
 			s1.Fields = append(s1.Fields, f2)
 		}
 	}
+
+	// 두 번째 sample에 없는 필드도 optional
+	for _, f1 := range s1.Fields {
+		if !seen[f1.JSONName] && !f1.IsOptional {
+			f1.AddTrace("optional: missing from a later sample%s", fromSources(sources2))
+			f1.IsOptional = true
+		}
+	}
+}
+
+// mergeFieldType reconciles the kinds of a field seen in two samples. Kinds
+// that join on the lattice (see JoinTypes) are widened and their nested
+// structs or array elements merged; anything else is decided by the
+// promotion policy of the field. Every disagreement other than null is
+// recorded as a conflict.
+func (m *merger) mergeFieldType(s1 *Struct, f1, f2 *Field, observed string) {
+	if f1.Promotion == "" {
+		f1.Promotion = f2.Promotion
+	}
+	policy := m.policyFor(f1)
+	p, err := Promote(policy, f1.Type, f1.Variants, f2.Type, f2.Variants)
+	if err != nil {
+		m.reject(s1, f1, observed, policy, err)
+		return
+	}
+	if !p.Joined {
+		var resolution string
+		switch {
+		case p.Type == JSONVariant:
+			f1.Type, f1.Variants = p.Type, p.Variants
+			resolution = p.String()
+		case isScalar(f1.Type) && isScalar(f2.Type):
+			f1.Type, f1.Variants = p.Type, nil
+			resolution = "promoted to " + p.Type.String()
+		case p.Type == f1.Type:
+			resolution = "kept " + fieldKind(f1)
+		default:
+			adoptShape(f1, f2)
+			resolution = "took " + fieldKind(f2)
+		}
+		if policy != PolicyWidening {
+			resolution += " (" + policy.String() + ")"
+		}
+		f1.AddTrace("conflict: %s; %s", observed, resolution)
+		m.addConflict(s1, f1, observed, resolution)
+		return
+	}
+
+	if f1.Type != f2.Type {
+		f1.AddTrace("promoteType: %s + %s -> %s%s", f1.Type, f2.Type, p, fromSources(f2.Sources))
+		if f1.Type != JSONNull && f2.Type != JSONNull {
+			m.addConflict(s1, f1, observed, "widened to "+p.String())
+		}
+	}
+	if f1.Type == JSONNull {
+		f1.ElemType, f1.ElemNullable = f2.ElemType, f2.ElemNullable
+		f1.Variants = f2.Variants
+	}
+	f1.Type = p.Type
+	if p.Type == JSONVariant {
+		f1.Variants = p.Variants
+	}
+
+	switch p.Type {
+	case JSONObject:
+		m.mergeNested(f1, f2)
+	case JSONArray:
+		m.mergeArrayElements(s1, f1, f2, observed)
+	}
+}
+
+// mergeArrayElements reconciles the element kinds of an array field seen in
+// two samples. An empty array takes the elements of the other sample.
+func (m *merger) mergeArrayElements(s1 *Struct, f1, f2 *Field, observed string) {
+	k1, k2 := elemKind(f1), elemKind(f2)
+	policy := m.policyFor(f1)
+	p, err := Promote(policy, k1, f1.Variants, k2, f2.Variants)
+	if err != nil {
+		m.reject(s1, f1, observed, policy, err)
+		return
+	}
+	if !p.Joined {
+		var resolution string
+		switch {
+		case p.Type == JSONVariant:
+			f1.ElemType, f1.Variants = p.Type, p.Variants
+			resolution = "elements " + p.String()
+		case isScalar(k1) && isScalar(k2):
+			f1.ElemType, f1.Variants = p.Type, nil
+			resolution = "promoted elements to " + p.Type.String()
+		case p.Type == k1:
+			resolution = "kept " + fieldKind(f1)
+		default:
+			adoptShape(f1, f2)
+			resolution = "took " + fieldKind(f2)
+		}
+		if policy != PolicyWidening {
+			resolution += " (" + policy.String() + ")"
+		}
+		f1.ElemNullable = f1.ElemNullable || f2.ElemNullable
+		f1.AddTrace("conflict: %s; %s", observed, resolution)
+		m.addConflict(s1, f1, observed, resolution)
+		return
+	}
+
+	if k1 != k2 && k1 != JSONNull && k2 != JSONNull {
+		f1.AddTrace("elements: %s + %s -> %s%s", k1, k2, p, fromSources(f2.Sources))
+		m.addConflict(s1, f1, observed, "widened elements to "+p.String())
+	}
+	if p.Type == JSONObject {
+		m.mergeNested(f1, f2)
+		return
+	}
+	f1.ElemType, f1.Variants = p.Type, p.Variants
+	f1.ElemNullable = f1.ElemNullable || f2.ElemNullable
+}
+
+// policyFor returns the promotion policy of a field.
+func (m *merger) policyFor(f *Field) PromotionPolicy {
+	if policy, ok := PromotionPolicies[f.Promotion]; ok {
+		return policy
+	}
+	return m.policy
+}
+
+// reject records a disagreement a policy refused; the field keeps its
+// earlier kind.
+func (m *merger) reject(s *Struct, f *Field, observed string, policy PromotionPolicy, err error) {
+	resolution := fmt.Sprintf("rejected (%s)", policy)
+	f.AddTrace("conflict: %s; %s", observed, resolution)
+	m.addConflict(s, f, observed, resolution)
+	if m.err == nil {
+		m.err = fmt.Errorf("%s.%s: %s: %w", s.Name, f.JSONName, observed, err)
+	}
+}
+
+// adoptShape gives f1 the kind, nested struct and elements of f2.
+func adoptShape(f1, f2 *Field) {
+	f1.Type, f1.NestedType = f2.Type, f2.NestedType
+	f1.ElemType, f1.ElemNullable, f1.Variants = f2.ElemType, f2.ElemNullable, f2.Variants
+}
+
+// mergeNested merges the struct of an object or array item field.
+func (m *merger) mergeNested(f1, f2 *Field) {
+	switch {
+	case f2.NestedType == nil:
+	case f1.NestedType == nil:
+		f1.NestedType = f2.NestedType
+	default:
+		m.mergeStruct(f1.NestedType, f2.NestedType)
+	}
+}
+
+func (m *merger) addConflict(s *Struct, f *Field, observed, resolution string) {
+	m.conflicts = append(m.conflicts, Conflict{Struct: s.Name, Field: f.JSONName, Observed: observed, Resolution: resolution})
+}
+
+// elemKind returns the element kind of an array field; JSONNull for an empty
+// array.
+func elemKind(f *Field) JSONType {
+	if f.NestedType != nil {
+		return JSONObject
+	}
+	return f.ElemType
+}
+
+// fieldKind describes the kind of a field for diagnostics.
+func fieldKind(f *Field) string {
+	switch {
+	case f.Type == JSONVariant:
+		return "variant of " + VariantKindNames(f.Variants)
+	case f.Type != JSONArray:
+		return f.Type.String()
+	case elemKind(f) == JSONNull:
+		return "empty array"
+	case elemKind(f) == JSONVariant:
+		return "array of variant of " + VariantKindNames(f.Variants)
+	default:
+		return "array of " + elemKind(f).String()
+	}
+}
+
+// describeField is fieldKind with the representation override, if any.
+func describeField(f *Field) string {
+	if f.Format == FormatDefault {
+		return fieldKind(f)
+	}
+	return fieldKind(f) + " (" + f.Format.String() + ")"
+}
+
+func isScalar(t JSONType) bool {
+	return t != JSONObject && t != JSONArray
+}
+
+// mergeUnionVariants adds the tag values only seen in s2 to the union of s1
+// and merges the variant structs of the values both have.
+func (m *merger) mergeUnionVariants(s1, s2 *Struct) {
+	if s2.Union == nil {
+		return
+	}
+	if s1.Union == nil {
+		s1.Union = s2.Union
+		return
+	}
+	for _, v2 := range s2.Union.Variants {
+		if v1 := s1.Union.Variant(v2.Value); v1 != nil {
+			m.mergeStruct(v1.Type, v2.Type)
+		} else {
+			s1.Union.Variants = append(s1.Union.Variants, v2)
+		}
+	}
+}
+
+// rewire points the references of s at the structs they were merged into.
+func (m *merger) rewire(s *Struct) {
+	for _, f := range s.Fields {
+		if t, ok := m.into[f.NestedType]; ok {
+			f.NestedType = t
+		}
+	}
+	if s.Union != nil {
+		for _, v := range s.Union.Variants {
+			if t, ok := m.into[v.Type]; ok {
+				v.Type = t
+			}
+		}
+	}
+	if t, ok := m.into[s.Base]; ok {
+		s.Base = t
+	}
+}
+
+// mergeFormat reconciles the representation overrides of a field seen in two
+// samples: numeric strings widen from int to float, any other disagreement
+// falls back to the plain representation. A null sample does not take part.
+func mergeFormat(f1, f2 Format) Format {
+	switch {
+	case f1 == f2:
+		return f1
+	case (f1 == FormatIntString && f2 == FormatFloatString) || (f1 == FormatFloatString && f2 == FormatIntString):
+		return FormatFloatString
+	default:
+		return FormatDefault
+	}
+}
+
+// Variant returns the variant selected by a tag value, or nil.
+func (u *Union) Variant(value string) *Variant {
+	for _, v := range u.Variants {
+		if v.Value == value {
+			return v
+		}
+	}
+	return nil
 }
 
 func promoteType(t1, t2 JSONType) JSONType {
@@ -153,6 +716,26 @@ func promoteType(t1, t2 JSONType) JSONType {
 	return t1
 }
 
+// JoinTypes returns the least upper bound of two value kinds on the
+// inference lattice: null joins with anything (the result is nullable),
+// int widens to float, and equal kinds join to themselves. ok is false when
+// the kinds have no common representation; the caller then decides how to
+// fall back (and should warn).
+func JoinTypes(t1, t2 JSONType) (result JSONType, ok bool) {
+	switch {
+	case t1 == t2:
+		return t1, true
+	case t1 == JSONNull:
+		return t2, true
+	case t2 == JSONNull:
+		return t1, true
+	case (t1 == JSONInt && t2 == JSONFloat) || (t1 == JSONFloat && t2 == JSONInt):
+		return JSONFloat, true
+	default:
+		return promoteType(t1, t2), false
+	}
+}
+
 func GenerateStructName(key string) string {
 	// Use centralized sanitizer to produce PascalCase struct/type names.
 	return nameutil.SanitizeToCppIdentifier(key, false, true)
@@ -164,10 +747,18 @@ func SortStructs(structs []*Struct) {
 	for _, s := range structs {
 		deps := []string{}
 		for _, f := range s.Fields {
-			if f.Type == JSONObject && f.NestedType != nil {
+			if (f.Type == JSONObject || f.Type == JSONArray) && f.NestedType != nil {
 				deps = append(deps, f.NestedType.Name)
 			}
 		}
+		if s.Union != nil {
+			for _, v := range s.Union.Variants {
+				deps = append(deps, v.Type.Name)
+			}
+		}
+		if s.Base != nil {
+			deps = append(deps, s.Base.Name)
+		}
 		graph[s.Name] = deps
 	}
 
@@ -0,0 +1,268 @@
+package types
+
+import "testing"
+
+func TestMergeTypesDeep(t *testing.T) {
+	sample := func(keys ...string) []*Struct {
+		address := &Struct{Name: "Address"}
+		for _, k := range keys {
+			address.Fields = append(address.Fields, &Field{Name: k, JSONName: k, Type: JSONString})
+		}
+		item := &Struct{Name: "OrdersItem", Fields: []*Field{
+			{Name: "address", JSONName: "address", Type: JSONObject, NestedType: address},
+		}}
+		root := &Struct{Name: "Root", Fields: []*Field{
+			{Name: "orders", JSONName: "orders", Type: JSONArray, NestedType: item},
+		}}
+		return []*Struct{address, item, root}
+	}
+
+	first := sample("city")
+	second := sample("city", "zip")
+	second[0].Name = "ShippingAddress" // reached through the same field under another name
+
+	merged := MergeTypes(first, second)
+	if len(merged) != 3 {
+		t.Fatalf("got %d structs, want 3", len(merged))
+	}
+
+	root := merged[2]
+	item := root.Fields[0].NestedType
+	address := item.Fields[0].NestedType
+	if item != merged[1] || address != merged[0] {
+		t.Fatal("references do not point at the merged structs")
+	}
+	if len(address.Fields) != 2 {
+		t.Fatalf("Address has %d fields, want 2", len(address.Fields))
+	}
+	if zip := address.Fields[1]; zip.JSONName != "zip" || !zip.IsOptional {
+		t.Errorf("zip = %+v, want an optional field", zip)
+	}
+	if city := address.Fields[0]; city.IsOptional {
+		t.Error("city is in every sample but became optional")
+	}
+
+	// Same-named structs within one sample are merged too
+	inner := &Struct{Name: "Address", Fields: []*Field{{Name: "city", JSONName: "city", Type: JSONString}}}
+	outer := &Struct{Name: "Address", Fields: []*Field{{Name: "zip", JSONName: "zip", Type: JSONString}}}
+	if got := MergeTypes(nil, []*Struct{inner, outer}); len(got) != 1 || len(got[0].Fields) != 2 {
+		t.Errorf("same-named structs of one sample were not merged: %d structs", len(got))
+	}
+
+	// A field missing from a later sample becomes optional too
+	merged = MergeTypes(merged, sample())
+	if city := merged[0].Fields[0]; !city.IsOptional {
+		t.Error("city is missing from the third sample but stayed required")
+	}
+}
+
+func TestMergeTypesConflicts(t *testing.T) {
+	nested := &Struct{Name: "S", Fields: []*Field{{Name: "k", JSONName: "k", Type: JSONInt}}}
+	first := []*Struct{{Name: "Root", Fields: []*Field{
+		{Name: "xs", JSONName: "xs", Type: JSONArray, ElemType: JSONInt},
+		{Name: "empty", JSONName: "empty", Type: JSONArray},
+		{Name: "s", JSONName: "s", Type: JSONString},
+		{Name: "n", JSONName: "n", Type: JSONBool},
+	}}}
+	second := []*Struct{nested, {Name: "Root", Fields: []*Field{
+		{Name: "xs", JSONName: "xs", Type: JSONArray, ElemType: JSONFloat, ElemNullable: true},
+		{Name: "empty", JSONName: "empty", Type: JSONArray, ElemType: JSONString},
+		{Name: "s", JSONName: "s", Type: JSONObject, NestedType: nested},
+		{Name: "n", JSONName: "n", Type: JSONInt},
+	}}}
+
+	merged, conflicts := MergeTypesWithConflicts(first, second)
+
+	root := merged[0]
+	if xs := root.Fields[0]; xs.ElemType != JSONFloat || !xs.ElemNullable {
+		t.Errorf("xs elements = %v (nullable %v), want nullable float", xs.ElemType, xs.ElemNullable)
+	}
+	if empty := root.Fields[1]; empty.ElemType != JSONString {
+		t.Errorf("empty array elements = %v, want string", empty.ElemType)
+	}
+	if s := root.Fields[2]; s.Type != JSONString || s.NestedType != nil {
+		t.Errorf("s = %v with nested %v, want the earlier string", s.Type, s.NestedType)
+	}
+	if n := root.Fields[3]; n.Type != JSONInt {
+		t.Errorf("n = %v, want bool + int promoted to int", n.Type)
+	}
+	if len(merged) != 1 {
+		t.Errorf("got %d structs, want 1: S is only used by the rejected object", len(merged))
+	}
+
+	want := []struct{ field, resolution string }{
+		{"xs", "widened elements to float"},
+		{"s", "kept string"},
+		{"n", "promoted to int"},
+	}
+	if len(conflicts) != len(want) {
+		t.Fatalf("got %d conflicts, want %d: %v", len(conflicts), len(want), conflicts)
+	}
+	for i, w := range want {
+		if c := conflicts[i]; c.Field != w.field || c.Resolution != w.resolution {
+			t.Errorf("conflict %d = %v, want %s resolved as %q", i, c, w.field, w.resolution)
+		}
+	}
+}
+
+func TestMergeTypesPolicies(t *testing.T) {
+	sample := func(value JSONType, shape *Field) []*Struct {
+		nested := &Struct{Name: "Shape", Fields: []*Field{{Name: "k", JSONName: "k", Type: JSONInt}}}
+		if shape.Type == JSONObject {
+			shape.NestedType = nested
+		}
+		return []*Struct{nested, {Name: "Root", Fields: []*Field{
+			{Name: "value", JSONName: "value", Type: value},
+			shape,
+		}}}
+	}
+	first := func() []*Struct {
+		return sample(JSONInt, &Field{Name: "shape", JSONName: "shape", Type: JSONString})
+	}
+	second := func() []*Struct {
+		return sample(JSONString, &Field{Name: "shape", JSONName: "shape", Type: JSONObject})
+	}
+
+	tests := []struct {
+		policy    PromotionPolicy
+		value     string
+		shape     string
+		wantError bool
+	}{
+		{PolicyWidening, "string", "string", false},
+		{PolicyVariant, "variant of int, string", "string", false},
+		{PolicyLastWins, "string", "object", false},
+		{PolicyError, "int", "string", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			merged, conflicts, err := MergeTypesWithPolicy(rootOnly(first()), second(), tt.policy)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("err = %v, want error %v", err, tt.wantError)
+			}
+			if len(conflicts) != 2 {
+				t.Errorf("got %d conflicts, want 2: %v", len(conflicts), conflicts)
+			}
+			root := findStruct(merged, "Root")
+			if got := fieldKind(root.Fields[0]); got != tt.value {
+				t.Errorf("value = %s, want %s", got, tt.value)
+			}
+			if got := fieldKind(root.Fields[1]); got != tt.shape {
+				t.Errorf("shape = %s, want %s", got, tt.shape)
+			}
+			if wantShape := tt.shape == "object"; (findStruct(merged, "Shape") != nil) != wantShape {
+				t.Errorf("Shape struct emitted = %v, want %v", !wantShape, wantShape)
+			}
+		})
+	}
+
+	// A field naming its own policy overrides the default
+	override := rootOnly(first())
+	override[0].Fields[0].Promotion = PolicyVariant.String()
+	merged, _, err := MergeTypesWithPolicy(override, second(), PolicyError)
+	if err == nil {
+		t.Error("shape was merged by the error policy without an error")
+	}
+	if got := fieldKind(findStruct(merged, "Root").Fields[0]); got != "variant of int, string" {
+		t.Errorf("value with a variant override = %s, want variant of int, string", got)
+	}
+
+	// A variant absorbs the kinds it holds and widens int to float
+	p, err := Promote(PolicyError, JSONVariant, []JSONType{JSONInt, JSONString}, JSONFloat, nil)
+	if err != nil || !p.Joined || p.String() != "variant of float, string" {
+		t.Errorf("Promote(variant of int, string; float) = %v, %v, want joined variant of float, string", p, err)
+	}
+}
+
+// rootOnly drops the structs not referenced from Root, as a sample without a
+// nested object would have.
+func rootOnly(structs []*Struct) []*Struct {
+	return []*Struct{findStruct(structs, "Root")}
+}
+
+func findStruct(structs []*Struct, name string) *Struct {
+	for _, s := range structs {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func TestMergeTypesNullable(t *testing.T) {
+	sample := func(fields ...*Field) []*Struct {
+		return []*Struct{{Name: "Root", Fields: fields}}
+	}
+
+	merged := MergeTypes(
+		sample(&Field{Name: "nick", JSONName: "nick", Type: JSONNull, Nullable: true}),
+		sample(&Field{Name: "nick", JSONName: "nick", Type: JSONString}),
+	)
+	merged = MergeTypes(merged, sample())
+	nick := merged[0].Fields[0]
+	if nick.Type != JSONString || !nick.Nullable || !nick.IsOptional {
+		t.Errorf("nick = %+v, want an optional nullable string", nick)
+	}
+
+	merged = MergeTypes(
+		sample(&Field{Name: "id", JSONName: "id", Type: JSONInt}),
+		sample(&Field{Name: "id", JSONName: "id", Type: JSONInt}),
+	)
+	if merged[0].Fields[0].Nullable {
+		t.Error("id was never null but became nullable")
+	}
+}
+
+func TestMergeTypesNullFormat(t *testing.T) {
+	sample := func(f *Field) []*Struct {
+		f.Name, f.JSONName = "price", "price"
+		return []*Struct{{Name: "Root", Fields: []*Field{f}}}
+	}
+	decimal := func() *Field {
+		return &Field{Type: JSONFloat, Format: FormatDecimal, CppType: "Decimal"}
+	}
+	null := func() *Field {
+		return &Field{Type: JSONNull, Nullable: true}
+	}
+
+	tests := []struct {
+		name          string
+		first, second *Field
+	}{
+		{"null first", null(), decimal()},
+		{"null second", decimal(), null()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts, err := MergeTypesWithPolicy(sample(tt.first), sample(tt.second), PolicyError)
+			if err != nil || len(conflicts) > 0 {
+				t.Fatalf("err = %v, conflicts = %v, want a clean merge", err, conflicts)
+			}
+			price := merged[0].Fields[0]
+			if price.Type != JSONFloat || price.Format != FormatDecimal || price.CppType != "Decimal" || !price.Nullable {
+				t.Errorf("price = %+v, want a nullable Decimal", price)
+			}
+		})
+	}
+}
+
+func TestDescribeJSONType(t *testing.T) {
+	item := &Struct{Name: "Item"}
+	tests := []struct {
+		name  string
+		field *Field
+		want  string
+	}{
+		{"scalar", &Field{Type: JSONString}, "string"},
+		{"array of objects", &Field{Type: JSONArray, NestedType: item}, "array of object"},
+		{"array of scalars", &Field{Type: JSONArray, ElemType: JSONInt}, "array of int"},
+		{"nullable elements", &Field{Type: JSONArray, ElemType: JSONFloat, ElemNullable: true}, "array of nullable float"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DescribeJSONType(tt.field); got != tt.want {
+				t.Errorf("DescribeJSONType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}